@@ -0,0 +1,142 @@
+package gocent
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned instead of making a request when the circuit
+// breaker is open.
+var ErrBreakerOpen = errors.New("gocent: circuit breaker open")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// BreakerConfig configures a circuit breaker guarding Send requests. The
+// zero value means "no circuit breaker" (Threshold 0 disables it).
+type BreakerConfig struct {
+	// Window is the rolling period over which failures are counted.
+	Window time.Duration
+	// Threshold is how many failures inside Window trip the breaker from
+	// closed to open. 0 disables the breaker.
+	Threshold int
+	// OpenTimeout is how long the breaker stays open before allowing a
+	// single half-open probe request through.
+	OpenTimeout time.Duration
+}
+
+// breaker is a minimal closed/open/half-open circuit breaker tracking
+// failures in a rolling time window.
+type breaker struct {
+	cfg BreakerConfig
+
+	mu            sync.Mutex
+	state         breakerState
+	failures      []time.Time
+	openUntil     time.Time
+	halfOpenProbe bool // true while a half-open probe request is in flight
+}
+
+func newBreaker(cfg BreakerConfig) *breaker {
+	return &breaker{cfg: cfg}
+}
+
+// allow reports whether a request may proceed, transitioning open -> half-open
+// once OpenTimeout has elapsed. Only a single probe request is let through
+// while half-open; concurrent callers are turned away until that probe's
+// recordSuccess/recordFailure resolves the state.
+func (b *breaker) allow() bool {
+	if b == nil || b.cfg.Threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if time.Now().Before(b.openUntil) {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.halfOpenProbe = true
+		return true
+	case breakerHalfOpen:
+		if b.halfOpenProbe {
+			return false
+		}
+		b.halfOpenProbe = true
+		return true
+	default:
+		return true
+	}
+}
+
+// recordSuccess closes the breaker.
+func (b *breaker) recordSuccess() {
+	if b == nil || b.cfg.Threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = breakerClosed
+	b.failures = nil
+	b.halfOpenProbe = false
+}
+
+// recordFailure records a failure and trips the breaker open if Threshold
+// failures have occurred inside Window (or immediately, if called while
+// half-open).
+func (b *breaker) recordFailure() {
+	if b == nil || b.cfg.Threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == breakerHalfOpen {
+		b.trip()
+		return
+	}
+
+	now := time.Now()
+	b.failures = append(b.failures, now)
+
+	window := b.cfg.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+	cutoff := now.Add(-window)
+	kept := b.failures[:0]
+	for _, t := range b.failures {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	b.failures = kept
+
+	if len(b.failures) >= b.cfg.Threshold {
+		b.trip()
+	}
+}
+
+// trip must be called with b.mu held.
+func (b *breaker) trip() {
+	b.state = breakerOpen
+	timeout := b.cfg.OpenTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	b.openUntil = time.Now().Add(timeout)
+	b.failures = nil
+	b.halfOpenProbe = false
+}
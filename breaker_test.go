@@ -0,0 +1,125 @@
+package gocent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerDisabledByDefault(t *testing.T) {
+	b := newBreaker(BreakerConfig{})
+	if !b.allow() {
+		t.Fatal("allow() = false, want true when Threshold is 0")
+	}
+	for i := 0; i < 10; i++ {
+		b.recordFailure()
+	}
+	if !b.allow() {
+		t.Fatal("allow() = false after failures, want true when breaker is disabled")
+	}
+}
+
+func TestBreakerNilReceiverSafe(t *testing.T) {
+	var b *breaker
+	if !b.allow() {
+		t.Fatal("nil breaker allow() = false, want true")
+	}
+	b.recordFailure()
+	b.recordSuccess()
+}
+
+func TestBreakerTripsAfterThreshold(t *testing.T) {
+	b := newBreaker(BreakerConfig{Threshold: 3, Window: time.Minute, OpenTimeout: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		b.recordFailure()
+		if !b.allow() {
+			t.Fatalf("allow() = false after %d failures, want true (threshold is 3)", i+1)
+		}
+	}
+
+	b.recordFailure()
+	if b.allow() {
+		t.Fatal("allow() = true after reaching threshold, want false (breaker open)")
+	}
+}
+
+func TestBreakerFailuresOutsideWindowDontCount(t *testing.T) {
+	b := newBreaker(BreakerConfig{Threshold: 2, Window: time.Millisecond, OpenTimeout: time.Hour})
+
+	b.recordFailure()
+	time.Sleep(5 * time.Millisecond)
+	b.recordFailure()
+
+	if !b.allow() {
+		t.Fatal("allow() = false, want true: failures were outside the rolling window")
+	}
+}
+
+func TestBreakerHalfOpenAfterTimeout(t *testing.T) {
+	b := newBreaker(BreakerConfig{Threshold: 1, Window: time.Minute, OpenTimeout: time.Millisecond})
+
+	b.recordFailure()
+	if b.allow() {
+		t.Fatal("allow() = true immediately after tripping, want false")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("allow() = false after OpenTimeout elapsed, want true (half-open probe)")
+	}
+}
+
+func TestBreakerHalfOpenFailureReopens(t *testing.T) {
+	b := newBreaker(BreakerConfig{Threshold: 1, Window: time.Minute, OpenTimeout: time.Millisecond})
+
+	b.recordFailure()
+	time.Sleep(5 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("allow() = false, want true (half-open probe)")
+	}
+
+	b.recordFailure()
+	if b.allow() {
+		t.Fatal("allow() = true right after a half-open probe failed, want false (reopened)")
+	}
+}
+
+func TestBreakerHalfOpenOnlyAllowsOneProbe(t *testing.T) {
+	b := newBreaker(BreakerConfig{Threshold: 1, Window: time.Minute, OpenTimeout: time.Millisecond})
+
+	b.recordFailure()
+	time.Sleep(5 * time.Millisecond)
+
+	if !b.allow() {
+		t.Fatal("allow() = false for the first half-open caller, want true (the probe)")
+	}
+	if b.allow() {
+		t.Fatal("allow() = true for a second concurrent half-open caller, want false: only one probe may be in flight")
+	}
+
+	b.recordFailure()
+	if b.allow() {
+		t.Fatal("allow() = true right after the probe failed, want false (reopened)")
+	}
+}
+
+func TestBreakerSuccessCloses(t *testing.T) {
+	b := newBreaker(BreakerConfig{Threshold: 2, Window: time.Minute, OpenTimeout: time.Millisecond})
+
+	b.recordFailure()
+	b.recordFailure()
+	time.Sleep(5 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("allow() = false, want true (half-open probe)")
+	}
+
+	b.recordSuccess()
+	if !b.allow() {
+		t.Fatal("allow() = false after recordSuccess, want true (breaker closed)")
+	}
+
+	b.recordFailure()
+	if !b.allow() {
+		t.Fatal("allow() = false after a single failure post-close, want true (below threshold again)")
+	}
+}
@@ -0,0 +1,301 @@
+// Package apipb contains the request/response messages for Centrifugo's
+// CentrifugoApi gRPC service, as defined in
+// github.com/scarbo87/gocent/grpc/centrifugo.proto. These are hand-written
+// rather than protoc-gen-go output (no protoc in this module's build), so
+// they implement only the legacy Reset/String/ProtoMessage trio and rely on
+// the protobuf struct tags plus Codec (see codec.go) for wire compatibility
+// instead of generated ProtoReflect support.
+package apipb
+
+import "fmt"
+
+type PublishRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Data    []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Client  string `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+	Uid     string `protobuf:"bytes,4,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *PublishRequest) Reset()         { *m = PublishRequest{} }
+func (m *PublishRequest) String() string { return protoString(m) }
+func (*PublishRequest) ProtoMessage()    {}
+
+type PublishResponse struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *PublishResponse) Reset()         { *m = PublishResponse{} }
+func (m *PublishResponse) String() string { return protoString(m) }
+func (*PublishResponse) ProtoMessage()    {}
+
+type BroadcastRequest struct {
+	Channels []string `protobuf:"bytes,1,rep,name=channels,proto3" json:"channels,omitempty"`
+	Data     []byte   `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Client   string   `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+	Uid      string   `protobuf:"bytes,4,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *BroadcastRequest) Reset()         { *m = BroadcastRequest{} }
+func (m *BroadcastRequest) String() string { return protoString(m) }
+func (*BroadcastRequest) ProtoMessage()    {}
+
+type BroadcastResponse struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *BroadcastResponse) Reset()         { *m = BroadcastResponse{} }
+func (m *BroadcastResponse) String() string { return protoString(m) }
+func (*BroadcastResponse) ProtoMessage()    {}
+
+type PresenceRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Uid     string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *PresenceRequest) Reset()         { *m = PresenceRequest{} }
+func (m *PresenceRequest) String() string { return protoString(m) }
+func (*PresenceRequest) ProtoMessage()    {}
+
+type ClientInfo struct {
+	User     string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Client   string `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+	ConnInfo []byte `protobuf:"bytes,3,opt,name=conn_info,json=connInfo,proto3" json:"conn_info,omitempty"`
+	ChanInfo []byte `protobuf:"bytes,4,opt,name=chan_info,json=chanInfo,proto3" json:"chan_info,omitempty"`
+}
+
+func (m *ClientInfo) Reset()         { *m = ClientInfo{} }
+func (m *ClientInfo) String() string { return protoString(m) }
+func (*ClientInfo) ProtoMessage()    {}
+
+type PresenceResponse struct {
+	Error    string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Presence map[string]*ClientInfo `protobuf:"bytes,2,rep,name=presence,proto3" json:"presence,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *PresenceResponse) Reset()         { *m = PresenceResponse{} }
+func (m *PresenceResponse) String() string { return protoString(m) }
+func (*PresenceResponse) ProtoMessage()    {}
+
+type PresenceStatsRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Uid     string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *PresenceStatsRequest) Reset()         { *m = PresenceStatsRequest{} }
+func (m *PresenceStatsRequest) String() string { return protoString(m) }
+func (*PresenceStatsRequest) ProtoMessage()    {}
+
+type PresenceStatsResponse struct {
+	Error      string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	NumClients int32  `protobuf:"varint,2,opt,name=num_clients,json=numClients,proto3" json:"num_clients,omitempty"`
+	NumUsers   int32  `protobuf:"varint,3,opt,name=num_users,json=numUsers,proto3" json:"num_users,omitempty"`
+}
+
+func (m *PresenceStatsResponse) Reset()         { *m = PresenceStatsResponse{} }
+func (m *PresenceStatsResponse) String() string { return protoString(m) }
+func (*PresenceStatsResponse) ProtoMessage()    {}
+
+type HistoryRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Uid     string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *HistoryRequest) Reset()         { *m = HistoryRequest{} }
+func (m *HistoryRequest) String() string { return protoString(m) }
+func (*HistoryRequest) ProtoMessage()    {}
+
+type Publication struct {
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Uid  string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *Publication) Reset()         { *m = Publication{} }
+func (m *Publication) String() string { return protoString(m) }
+func (*Publication) ProtoMessage()    {}
+
+type HistoryResponse struct {
+	Error        string         `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Publications []*Publication `protobuf:"bytes,2,rep,name=publications,proto3" json:"publications,omitempty"`
+}
+
+func (m *HistoryResponse) Reset()         { *m = HistoryResponse{} }
+func (m *HistoryResponse) String() string { return protoString(m) }
+func (*HistoryResponse) ProtoMessage()    {}
+
+type HistoryRemoveRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Uid     string `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *HistoryRemoveRequest) Reset()         { *m = HistoryRemoveRequest{} }
+func (m *HistoryRemoveRequest) String() string { return protoString(m) }
+func (*HistoryRemoveRequest) ProtoMessage()    {}
+
+type HistoryRemoveResponse struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *HistoryRemoveResponse) Reset()         { *m = HistoryRemoveResponse{} }
+func (m *HistoryRemoveResponse) String() string { return protoString(m) }
+func (*HistoryRemoveResponse) ProtoMessage()    {}
+
+type ChannelsRequest struct {
+	Uid string `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *ChannelsRequest) Reset()         { *m = ChannelsRequest{} }
+func (m *ChannelsRequest) String() string { return protoString(m) }
+func (*ChannelsRequest) ProtoMessage()    {}
+
+type ChannelsResponse struct {
+	Error    string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Channels []string `protobuf:"bytes,2,rep,name=channels,proto3" json:"channels,omitempty"`
+}
+
+func (m *ChannelsResponse) Reset()         { *m = ChannelsResponse{} }
+func (m *ChannelsResponse) String() string { return protoString(m) }
+func (*ChannelsResponse) ProtoMessage()    {}
+
+type InfoRequest struct {
+	Uid string `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *InfoRequest) Reset()         { *m = InfoRequest{} }
+func (m *InfoRequest) String() string { return protoString(m) }
+func (*InfoRequest) ProtoMessage()    {}
+
+type NodeInfo struct {
+	Uid         string `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	NumClients  uint32 `protobuf:"varint,2,opt,name=num_clients,json=numClients,proto3" json:"num_clients,omitempty"`
+	NumUsers    uint32 `protobuf:"varint,3,opt,name=num_users,json=numUsers,proto3" json:"num_users,omitempty"`
+	NumChannels uint32 `protobuf:"varint,4,opt,name=num_channels,json=numChannels,proto3" json:"num_channels,omitempty"`
+	Uptime      int64  `protobuf:"varint,5,opt,name=uptime,proto3" json:"uptime,omitempty"`
+}
+
+func (m *NodeInfo) Reset()         { *m = NodeInfo{} }
+func (m *NodeInfo) String() string { return protoString(m) }
+func (*NodeInfo) ProtoMessage()    {}
+
+type InfoResponse struct {
+	Error string      `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Nodes []*NodeInfo `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+}
+
+func (m *InfoResponse) Reset()         { *m = InfoResponse{} }
+func (m *InfoResponse) String() string { return protoString(m) }
+func (*InfoResponse) ProtoMessage()    {}
+
+type SubscribeRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	User    string `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Client  string `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+	Uid     string `protobuf:"bytes,4,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *SubscribeRequest) Reset()         { *m = SubscribeRequest{} }
+func (m *SubscribeRequest) String() string { return protoString(m) }
+func (*SubscribeRequest) ProtoMessage()    {}
+
+type SubscribeResponse struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *SubscribeResponse) Reset()         { *m = SubscribeResponse{} }
+func (m *SubscribeResponse) String() string { return protoString(m) }
+func (*SubscribeResponse) ProtoMessage()    {}
+
+type UnsubscribeRequest struct {
+	Channel string `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	User    string `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	Client  string `protobuf:"bytes,3,opt,name=client,proto3" json:"client,omitempty"`
+	Uid     string `protobuf:"bytes,4,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *UnsubscribeRequest) Reset()         { *m = UnsubscribeRequest{} }
+func (m *UnsubscribeRequest) String() string { return protoString(m) }
+func (*UnsubscribeRequest) ProtoMessage()    {}
+
+type UnsubscribeResponse struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *UnsubscribeResponse) Reset()         { *m = UnsubscribeResponse{} }
+func (m *UnsubscribeResponse) String() string { return protoString(m) }
+func (*UnsubscribeResponse) ProtoMessage()    {}
+
+type DisconnectRequest struct {
+	User   string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Client string `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+	Uid    string `protobuf:"bytes,3,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *DisconnectRequest) Reset()         { *m = DisconnectRequest{} }
+func (m *DisconnectRequest) String() string { return protoString(m) }
+func (*DisconnectRequest) ProtoMessage()    {}
+
+type DisconnectResponse struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *DisconnectResponse) Reset()         { *m = DisconnectResponse{} }
+func (m *DisconnectResponse) String() string { return protoString(m) }
+func (*DisconnectResponse) ProtoMessage()    {}
+
+type RefreshRequest struct {
+	User   string `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Client string `protobuf:"bytes,2,opt,name=client,proto3" json:"client,omitempty"`
+	Uid    string `protobuf:"bytes,3,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *RefreshRequest) Reset()         { *m = RefreshRequest{} }
+func (m *RefreshRequest) String() string { return protoString(m) }
+func (*RefreshRequest) ProtoMessage()    {}
+
+type RefreshResponse struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (m *RefreshResponse) Reset()         { *m = RefreshResponse{} }
+func (m *RefreshResponse) String() string { return protoString(m) }
+func (*RefreshResponse) ProtoMessage()    {}
+
+type CommandItem struct {
+	Method string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	Params []byte `protobuf:"bytes,2,opt,name=params,proto3" json:"params,omitempty"`
+	Uid    string `protobuf:"bytes,3,opt,name=uid,proto3" json:"uid,omitempty"`
+}
+
+func (m *CommandItem) Reset()         { *m = CommandItem{} }
+func (m *CommandItem) String() string { return protoString(m) }
+func (*CommandItem) ProtoMessage()    {}
+
+type BatchRequest struct {
+	Commands []*CommandItem `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
+}
+
+func (m *BatchRequest) Reset()         { *m = BatchRequest{} }
+func (m *BatchRequest) String() string { return protoString(m) }
+func (*BatchRequest) ProtoMessage()    {}
+
+type ResultItem struct {
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Body  []byte `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
+}
+
+func (m *ResultItem) Reset()         { *m = ResultItem{} }
+func (m *ResultItem) String() string { return protoString(m) }
+func (*ResultItem) ProtoMessage()    {}
+
+type BatchResponse struct {
+	Results []*ResultItem `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+}
+
+func (m *BatchResponse) Reset()         { *m = BatchResponse{} }
+func (m *BatchResponse) String() string { return protoString(m) }
+func (*BatchResponse) ProtoMessage()    {}
+
+// protoString gives messages a readable %v/String() without pulling in the
+// full proto reflection machinery real protoc-gen-go output would use.
+func protoString(m interface{}) string {
+	return fmt.Sprintf("%+v", m)
+}
@@ -0,0 +1,114 @@
+// Package apipb: client stubs for the CentrifugoApi gRPC service. Hand
+// written alongside centrifugo.pb.go rather than generated by
+// protoc-gen-go-grpc (see that file's package comment for why).
+package apipb
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// CentrifugoApiClient is the client API for CentrifugoApi service.
+type CentrifugoApiClient interface {
+	Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error)
+	Broadcast(ctx context.Context, in *BroadcastRequest, opts ...grpc.CallOption) (*BroadcastResponse, error)
+	Presence(ctx context.Context, in *PresenceRequest, opts ...grpc.CallOption) (*PresenceResponse, error)
+	PresenceStats(ctx context.Context, in *PresenceStatsRequest, opts ...grpc.CallOption) (*PresenceStatsResponse, error)
+	History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error)
+	HistoryRemove(ctx context.Context, in *HistoryRemoveRequest, opts ...grpc.CallOption) (*HistoryRemoveResponse, error)
+	Channels(ctx context.Context, in *ChannelsRequest, opts ...grpc.CallOption) (*ChannelsResponse, error)
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (*SubscribeResponse, error)
+	Unsubscribe(ctx context.Context, in *UnsubscribeRequest, opts ...grpc.CallOption) (*UnsubscribeResponse, error)
+	Disconnect(ctx context.Context, in *DisconnectRequest, opts ...grpc.CallOption) (*DisconnectResponse, error)
+	Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error)
+	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error)
+}
+
+type centrifugoApiClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewCentrifugoApiClient returns a CentrifugoApiClient backed by cc.
+func NewCentrifugoApiClient(cc *grpc.ClientConn) CentrifugoApiClient {
+	return &centrifugoApiClient{cc}
+}
+
+func (c *centrifugoApiClient) Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error) {
+	out := new(PublishResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Publish", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Broadcast(ctx context.Context, in *BroadcastRequest, opts ...grpc.CallOption) (*BroadcastResponse, error) {
+	out := new(BroadcastResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Broadcast", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Presence(ctx context.Context, in *PresenceRequest, opts ...grpc.CallOption) (*PresenceResponse, error) {
+	out := new(PresenceResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Presence", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) PresenceStats(ctx context.Context, in *PresenceStatsRequest, opts ...grpc.CallOption) (*PresenceStatsResponse, error) {
+	out := new(PresenceStatsResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/PresenceStats", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) History(ctx context.Context, in *HistoryRequest, opts ...grpc.CallOption) (*HistoryResponse, error) {
+	out := new(HistoryResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/History", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) HistoryRemove(ctx context.Context, in *HistoryRemoveRequest, opts ...grpc.CallOption) (*HistoryRemoveResponse, error) {
+	out := new(HistoryRemoveResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/HistoryRemove", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Channels(ctx context.Context, in *ChannelsRequest, opts ...grpc.CallOption) (*ChannelsResponse, error) {
+	out := new(ChannelsResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Channels", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Info", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (*SubscribeResponse, error) {
+	out := new(SubscribeResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Subscribe", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Unsubscribe(ctx context.Context, in *UnsubscribeRequest, opts ...grpc.CallOption) (*UnsubscribeResponse, error) {
+	out := new(UnsubscribeResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Unsubscribe", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Disconnect(ctx context.Context, in *DisconnectRequest, opts ...grpc.CallOption) (*DisconnectResponse, error) {
+	out := new(DisconnectResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Disconnect", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Refresh(ctx context.Context, in *RefreshRequest, opts ...grpc.CallOption) (*RefreshResponse, error) {
+	out := new(RefreshResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Refresh", in, out, opts...)
+	return out, err
+}
+
+func (c *centrifugoApiClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchResponse, error) {
+	out := new(BatchResponse)
+	err := c.cc.Invoke(ctx, "/centrifugo.CentrifugoApi/Batch", in, out, opts...)
+	return out, err
+}
@@ -0,0 +1,358 @@
+package apipb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrUnsupportedField is returned by Marshal/Unmarshal when a struct field's
+// Go type has no mapping to a protobuf wire representation.
+var ErrUnsupportedField = errors.New("apipb: unsupported field type")
+
+// Codec implements google.golang.org/grpc/encoding.Codec on top of
+// Marshal/Unmarshal below. The message types in this package are hand
+// written rather than protoc-gen-go output, so they don't satisfy
+// google.golang.org/protobuf/proto.Message (no ProtoReflect method) and
+// grpc's default codec rejects them with a type assertion failure. Installing
+// Codec via grpc.WithDefaultCallOptions(grpc.ForceCodec(apipb.Codec{})) makes
+// the ClientConn use this codec instead, producing genuine protobuf wire
+// bytes driven by the same "protobuf:..." struct tags real generated code
+// would carry.
+type Codec struct{}
+
+// Name implements encoding.Codec.
+func (Codec) Name() string { return "proto" }
+
+// Marshal implements encoding.Codec.
+func (Codec) Marshal(v interface{}) ([]byte, error) { return Marshal(v) }
+
+// Unmarshal implements encoding.Codec.
+func (Codec) Unmarshal(data []byte, v interface{}) error { return Unmarshal(data, v) }
+
+// Marshal encodes m, a pointer to one of this package's message structs, to
+// protobuf wire format using its "protobuf:..." struct tags.
+func Marshal(m interface{}) ([]byte, error) {
+	v := reflect.ValueOf(m)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	return marshalStruct(v)
+}
+
+// Unmarshal decodes protobuf wire format data into m, a pointer to one of
+// this package's message structs.
+func Unmarshal(data []byte, m interface{}) error {
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("apipb: Unmarshal requires a non-nil pointer, got %T", m)
+	}
+	return unmarshalStruct(data, v.Elem())
+}
+
+type fieldInfo struct {
+	index  int
+	number protowire.Number
+}
+
+// fieldsOf reads the field number out of each "protobuf:..." struct tag on
+// t, the same tags protoc-gen-go would have emitted.
+func fieldsOf(t reflect.Type) ([]fieldInfo, error) {
+	var fields []fieldInfo
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("protobuf")
+		if tag == "" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("apipb: malformed protobuf tag %q", tag)
+		}
+		num, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("apipb: malformed protobuf tag %q: %w", tag, err)
+		}
+		fields = append(fields, fieldInfo{index: i, number: protowire.Number(num)})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].number < fields[j].number })
+	return fields, nil
+}
+
+func marshalStruct(v reflect.Value) ([]byte, error) {
+	fields, err := fieldsOf(v.Type())
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	for _, f := range fields {
+		enc, err := marshalField(f.number, v.Field(f.index))
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, enc...)
+	}
+	return b, nil
+}
+
+func marshalField(num protowire.Number, fv reflect.Value) ([]byte, error) {
+	switch fv.Kind() {
+	case reflect.String:
+		return marshalString(num, fv.String()), nil
+	case reflect.Int32, reflect.Int64:
+		return marshalVarint(num, uint64(fv.Int())), nil
+	case reflect.Uint32, reflect.Uint64:
+		return marshalVarint(num, fv.Uint()), nil
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			return marshalBytes(num, fv.Bytes()), nil
+		}
+		var b []byte
+		for i := 0; i < fv.Len(); i++ {
+			enc, err := marshalRepeatedElem(num, fv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, enc...)
+		}
+		return b, nil
+	case reflect.Ptr:
+		if fv.IsNil() {
+			return nil, nil
+		}
+		sub, err := marshalStruct(fv.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return marshalBytes(num, sub), nil
+	case reflect.Map:
+		var b []byte
+		keys := fv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, k := range keys {
+			entry, err := marshalMapEntry(k, fv.MapIndex(k))
+			if err != nil {
+				return nil, err
+			}
+			b = append(b, marshalBytes(num, entry)...)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedField, fv.Kind())
+	}
+}
+
+func marshalRepeatedElem(num protowire.Number, ev reflect.Value) ([]byte, error) {
+	switch ev.Kind() {
+	case reflect.String:
+		return marshalString(num, ev.String()), nil
+	case reflect.Ptr:
+		sub, err := marshalStruct(ev.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return marshalBytes(num, sub), nil
+	default:
+		return nil, fmt.Errorf("%w: repeated %s", ErrUnsupportedField, ev.Kind())
+	}
+}
+
+// marshalMapEntry encodes a single map[string]*Message entry the way proto3
+// represents one: a nested message with the key as field 1 and the value as
+// field 2.
+func marshalMapEntry(k, v reflect.Value) ([]byte, error) {
+	b := marshalString(1, k.String())
+	if !v.IsNil() {
+		sub, err := marshalStruct(v.Elem())
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, marshalBytes(2, sub)...)
+	}
+	return b, nil
+}
+
+func marshalString(num protowire.Number, s string) []byte {
+	if s == "" {
+		return nil
+	}
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func marshalBytes(num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func marshalVarint(num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return nil
+	}
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func unmarshalStruct(data []byte, v reflect.Value) error {
+	fields, err := fieldsOf(v.Type())
+	if err != nil {
+		return err
+	}
+	byNumber := make(map[protowire.Number]fieldInfo, len(fields))
+	for _, f := range fields {
+		byNumber[f.number] = f
+	}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		f, known := byNumber[num]
+		if !known {
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			continue
+		}
+
+		switch typ {
+		case protowire.VarintType:
+			val, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			if err := setVarint(v.Field(f.index), val); err != nil {
+				return err
+			}
+		case protowire.BytesType:
+			val, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+			if err := setBytes(v.Field(f.index), val); err != nil {
+				return err
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return nil
+}
+
+func setVarint(fv reflect.Value, val uint64) error {
+	switch fv.Kind() {
+	case reflect.Int32, reflect.Int64:
+		fv.SetInt(int64(val))
+	case reflect.Uint32, reflect.Uint64:
+		fv.SetUint(val)
+	default:
+		return fmt.Errorf("%w: varint into %s", ErrUnsupportedField, fv.Kind())
+	}
+	return nil
+}
+
+func setBytes(fv reflect.Value, val []byte) error {
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(string(val))
+	case reflect.Slice:
+		et := fv.Type().Elem()
+		switch {
+		case et.Kind() == reflect.Uint8:
+			cp := make([]byte, len(val))
+			copy(cp, val)
+			fv.SetBytes(cp)
+		case et.Kind() == reflect.String:
+			fv.Set(reflect.Append(fv, reflect.ValueOf(string(val))))
+		case et.Kind() == reflect.Ptr:
+			ev := reflect.New(et.Elem())
+			if err := unmarshalStruct(val, ev.Elem()); err != nil {
+				return err
+			}
+			fv.Set(reflect.Append(fv, ev))
+		default:
+			return fmt.Errorf("%w: repeated %s", ErrUnsupportedField, et.Kind())
+		}
+	case reflect.Ptr:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return unmarshalStruct(val, fv.Elem())
+	case reflect.Map:
+		kt, vt := fv.Type().Key(), fv.Type().Elem()
+		if kt.Kind() != reflect.String || vt.Kind() != reflect.Ptr {
+			return fmt.Errorf("%w: map[%s]%s", ErrUnsupportedField, kt, vt)
+		}
+		key, value, err := unmarshalMapEntry(val, vt.Elem())
+		if err != nil {
+			return err
+		}
+		if fv.IsNil() {
+			fv.Set(reflect.MakeMap(fv.Type()))
+		}
+		fv.SetMapIndex(reflect.ValueOf(key), value)
+	default:
+		return fmt.Errorf("%w: bytes into %s", ErrUnsupportedField, fv.Kind())
+	}
+	return nil
+}
+
+func unmarshalMapEntry(data []byte, valType reflect.Type) (string, reflect.Value, error) {
+	var key string
+	value := reflect.New(valType)
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return "", reflect.Value{}, protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			b, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return "", reflect.Value{}, protowire.ParseError(n)
+			}
+			data = data[n:]
+			key = string(b)
+		case num == 2 && typ == protowire.BytesType:
+			b, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return "", reflect.Value{}, protowire.ParseError(n)
+			}
+			data = data[n:]
+			if err := unmarshalStruct(b, value.Elem()); err != nil {
+				return "", reflect.Value{}, err
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return "", reflect.Value{}, protowire.ParseError(n)
+			}
+			data = data[n:]
+		}
+	}
+	return key, value, nil
+}
@@ -0,0 +1,93 @@
+package apipb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarshalUnmarshalPublishRequest(t *testing.T) {
+	want := &PublishRequest{
+		Channel: "news",
+		Data:    []byte(`{"text":"hello"}`),
+		Client:  "client-1",
+		Uid:     "uid-1",
+	}
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	got := &PublishRequest{}
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshalUnmarshalPresenceResponseMap(t *testing.T) {
+	want := &PresenceResponse{
+		Presence: map[string]*ClientInfo{
+			"client-1": {User: "alice", Client: "client-1", ConnInfo: []byte("conn-a")},
+			"client-2": {User: "bob", Client: "client-2", ChanInfo: []byte("chan-b")},
+		},
+	}
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	got := &PresenceResponse{}
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshalUnmarshalHistoryResponseRepeated(t *testing.T) {
+	want := &HistoryResponse{
+		Publications: []*Publication{
+			{Data: []byte("first"), Uid: "uid-1"},
+			{Data: []byte("second"), Uid: "uid-2"},
+		},
+	}
+
+	data, err := Marshal(want)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	got := &HistoryResponse{}
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshalUnmarshalEmptyMessage(t *testing.T) {
+	data, err := Marshal(&PublishRequest{})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Marshal(zero value) = %v, want empty (proto3 omits zero fields)", data)
+	}
+
+	got := &PublishRequest{}
+	if err := Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, &PublishRequest{}) {
+		t.Errorf("round trip = %+v, want zero value", got)
+	}
+}
@@ -0,0 +1,388 @@
+// Package grpc provides a gocent.Transport implementation that talks to a
+// Centrifugo-shaped gRPC server API instead of the HTTP/JSON one, using the
+// hand-written stubs in the apipb subpackage.
+//
+// The service and messages in apipb (see centrifugo.proto) are NOT generated
+// from Centrifugo's own .proto definitions — Centrifugo does not ship one for
+// its server API, so apipb mirrors the command/response shapes of the
+// HTTP/JSON API (see Command/Response in the root package) as a best-effort
+// gRPC equivalent. Talking to a real Centrifugo server over gRPC requires
+// that server to expose a matching CentrifugoApi service; this package has
+// only been exercised against the fakes in its own tests.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+
+	json "github.com/json-iterator/go"
+	"github.com/scarbo87/gocent"
+	"github.com/scarbo87/gocent/grpc/apipb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// ErrUnknownMethod is returned when a Command carries a method name the
+// transport does not know how to translate into a CentrifugoApi RPC.
+var ErrUnknownMethod = errors.New("gocent/grpc: unknown command method")
+
+// Transport implements gocent.Transport on top of google.golang.org/grpc.
+// Unlike the default HTTP transport it never signs requests with
+// X-API-Sign: authentication is expected to be handled either by TLS client
+// certificates (configure WithTLSConfig) or by per-RPC metadata (configure
+// APIKey, sent as "authorization: apikey <key>", mirroring Centrifugo v2's
+// HTTP API key scheme).
+type Transport struct {
+	conn   *grpc.ClientConn
+	client apipb.CentrifugoApiClient
+
+	// APIKey, if set, is sent as per-RPC metadata instead of a TLS client
+	// certificate.
+	APIKey string
+}
+
+// Option configures a Transport constructed by NewTransport.
+type Option func(*dialOptions)
+
+type dialOptions struct {
+	tlsConfig   *tls.Config
+	apiKey      string
+	dialOptions []grpc.DialOption
+}
+
+// WithTLSConfig makes the transport dial Centrifugo using TLS client
+// certificates instead of an API key.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *dialOptions) {
+		o.tlsConfig = cfg
+	}
+}
+
+// WithAPIKey makes the transport send "authorization: apikey <key>" metadata
+// on every RPC, the gRPC equivalent of the HTTP transport's X-API-Sign.
+func WithAPIKey(key string) Option {
+	return func(o *dialOptions) {
+		o.apiKey = key
+	}
+}
+
+// WithDialOptions passes through additional grpc.DialOption values, for
+// callers who need keepalive tuning, interceptors or custom balancers.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *dialOptions) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// NewTransport dials addr and returns a Transport ready to be installed on a
+// gocent.Client with Client.SetTransport. The connection always uses
+// apipb.Codec instead of grpc's default proto codec, since the hand-written
+// message types in apipb implement the legacy Reset/String/ProtoMessage
+// trio rather than the ProtoReflect method the default codec requires.
+func NewTransport(addr string, opts ...Option) (*Transport, error) {
+	var o dialOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	dialOpts := append([]grpc.DialOption{grpc.WithDefaultCallOptions(grpc.ForceCodec(apipb.Codec{}))}, o.dialOptions...)
+	if o.tlsConfig != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(o.tlsConfig)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.Dial(addr, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transport{
+		conn:   conn,
+		client: apipb.NewCentrifugoApiClient(conn),
+		APIKey: o.apiKey,
+	}, nil
+}
+
+// Close closes the underlying gRPC connection. Callers that replace a
+// Client's Transport, or that are shutting down, must call Close to avoid
+// leaking the connection and its goroutines.
+func (t *Transport) Close() error {
+	return t.conn.Close()
+}
+
+// Do implements gocent.Transport. A single buffered command is sent as its
+// own unary RPC, the same routing do provides for every other caller of this
+// transport (subscribe/publish/... outside of Client.Send). Once Client.Send
+// has more than one command buffered, Do instead makes one Batch RPC call
+// carrying all of them, so N buffered commands cost one round trip instead
+// of N, matching the latency characteristics of the HTTP transport's single
+// POST per Send.
+func (t *Transport) Do(ctx context.Context, cmds []gocent.Command) (gocent.Result, error) {
+	if t.APIKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "apikey "+t.APIKey)
+	}
+
+	if len(cmds) > 1 {
+		return t.doBatch(ctx, cmds)
+	}
+
+	result := make(gocent.Result, 0, len(cmds))
+	for _, cmd := range cmds {
+		resp, err := t.do(ctx, cmd)
+		if err != nil {
+			return gocent.Result{}, err
+		}
+		result = append(result, resp)
+	}
+	return result, nil
+}
+
+// doBatch sends cmds as a single Batch RPC. Each command's Params is carried
+// as its JSON encoding (the same representation Command already uses
+// internally) inside a CommandItem rather than as a oneof of every typed
+// request message above; this is a unary RPC rather than the client-streaming
+// approach a generated stub would typically offer for this kind of
+// fan-in, since a single round trip already fixes the N-round-trips problem
+// and the hand-rolled reflection codec in apipb has no support for streaming
+// messages.
+func (t *Transport) doBatch(ctx context.Context, cmds []gocent.Command) (gocent.Result, error) {
+	items := make([]*apipb.CommandItem, len(cmds))
+	for i, cmd := range cmds {
+		params, err := json.Marshal(cmd.Params)
+		if err != nil {
+			return gocent.Result{}, err
+		}
+		items[i] = &apipb.CommandItem{Method: cmd.Method, Params: params, Uid: cmd.UID}
+	}
+
+	reply, err := t.client.Batch(ctx, &apipb.BatchRequest{Commands: items})
+	if err != nil {
+		return gocent.Result{}, err
+	}
+	if len(reply.Results) != len(cmds) {
+		return gocent.Result{}, gocent.ErrMalformedResponse
+	}
+
+	result := make(gocent.Result, len(reply.Results))
+	for i, r := range reply.Results {
+		result[i] = gocent.Response{Error: r.Error, Body: r.Body}
+	}
+	return result, nil
+}
+
+// do dispatches cmd to the unary RPC matching cmd.Method, the same routing
+// the HTTP transport gets for free by forwarding the raw Command JSON.
+func (t *Transport) do(ctx context.Context, cmd gocent.Command) (gocent.Response, error) {
+	switch cmd.Method {
+	case "publish":
+		reply, err := t.client.Publish(ctx, &apipb.PublishRequest{
+			Channel: stringParam(cmd.Params, "channel"),
+			Data:    bytesParam(cmd.Params, "data"),
+			Client:  stringParam(cmd.Params, "client"),
+			Uid:     cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return gocent.Response{Error: reply.Error}, nil
+
+	case "broadcast":
+		reply, err := t.client.Broadcast(ctx, &apipb.BroadcastRequest{
+			Channels: stringsParam(cmd.Params, "channels"),
+			Data:     bytesParam(cmd.Params, "data"),
+			Client:   stringParam(cmd.Params, "client"),
+			Uid:      cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return gocent.Response{Error: reply.Error}, nil
+
+	case "unsubscribe":
+		reply, err := t.client.Unsubscribe(ctx, &apipb.UnsubscribeRequest{
+			Channel: stringParam(cmd.Params, "channel"),
+			User:    stringParam(cmd.Params, "user"),
+			Client:  stringParam(cmd.Params, "client"),
+			Uid:     cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return gocent.Response{Error: reply.Error}, nil
+
+	case "disconnect":
+		reply, err := t.client.Disconnect(ctx, &apipb.DisconnectRequest{
+			User:   stringParam(cmd.Params, "user"),
+			Client: stringParam(cmd.Params, "client"),
+			Uid:    cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return gocent.Response{Error: reply.Error}, nil
+
+	case "presence":
+		reply, err := t.client.Presence(ctx, &apipb.PresenceRequest{
+			Channel: stringParam(cmd.Params, "channel"),
+			Uid:     cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return withData(reply.Error, presenceData(reply.Presence))
+
+	case "presence_stats":
+		reply, err := t.client.PresenceStats(ctx, &apipb.PresenceStatsRequest{
+			Channel: stringParam(cmd.Params, "channel"),
+			Uid:     cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return withData(reply.Error, presenceStatsData(reply))
+
+	case "history":
+		reply, err := t.client.History(ctx, &apipb.HistoryRequest{
+			Channel: stringParam(cmd.Params, "channel"),
+			Uid:     cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return withData(reply.Error, historyData(reply.Publications))
+
+	case "history_remove":
+		reply, err := t.client.HistoryRemove(ctx, &apipb.HistoryRemoveRequest{
+			Channel: stringParam(cmd.Params, "channel"),
+			Uid:     cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return gocent.Response{Error: reply.Error}, nil
+
+	case "subscribe":
+		reply, err := t.client.Subscribe(ctx, &apipb.SubscribeRequest{
+			Channel: stringParam(cmd.Params, "channel"),
+			User:    stringParam(cmd.Params, "user"),
+			Client:  stringParam(cmd.Params, "client"),
+			Uid:     cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return gocent.Response{Error: reply.Error}, nil
+
+	case "refresh":
+		reply, err := t.client.Refresh(ctx, &apipb.RefreshRequest{
+			User:   stringParam(cmd.Params, "user"),
+			Client: stringParam(cmd.Params, "client"),
+			Uid:    cmd.UID,
+		})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return gocent.Response{Error: reply.Error}, nil
+
+	case "channels":
+		reply, err := t.client.Channels(ctx, &apipb.ChannelsRequest{Uid: cmd.UID})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return withData(reply.Error, reply.Channels)
+
+	case "stats":
+		reply, err := t.client.Info(ctx, &apipb.InfoRequest{Uid: cmd.UID})
+		if err != nil {
+			return gocent.Response{}, err
+		}
+		return withData(reply.Error, statsData(reply.Nodes))
+
+	default:
+		return gocent.Response{}, ErrUnknownMethod
+	}
+}
+
+func stringParam(params map[string]interface{}, key string) string {
+	s, _ := params[key].(string)
+	return s
+}
+
+func stringsParam(params map[string]interface{}, key string) []string {
+	s, _ := params[key].([]string)
+	return s
+}
+
+func bytesParam(params map[string]interface{}, key string) []byte {
+	switch v := params[key].(type) {
+	case *json.RawMessage:
+		if v != nil {
+			return []byte(*v)
+		}
+	case json.RawMessage:
+		return []byte(v)
+	case []byte:
+		return v
+	}
+	return nil
+}
+
+// withData marshals v as the "data" envelope the Decode* helpers in the root
+// package unmarshal (e.g. DecodePresence, DecodeHistory), so a Response
+// produced by this transport decodes the same way as one from httpTransport.
+func withData(errStr string, v interface{}) (gocent.Response, error) {
+	body, err := json.Marshal(map[string]interface{}{"data": v})
+	if err != nil {
+		return gocent.Response{}, err
+	}
+	return gocent.Response{Error: errStr, Body: body}, nil
+}
+
+func presenceData(presence map[string]*apipb.ClientInfo) map[string]gocent.ClientInfo {
+	out := make(map[string]gocent.ClientInfo, len(presence))
+	for k, v := range presence {
+		out[k] = gocent.ClientInfo{
+			User:     v.User,
+			Client:   v.Client,
+			ConnInfo: v.ConnInfo,
+			ChanInfo: v.ChanInfo,
+		}
+	}
+	return out
+}
+
+// presenceStatsResult is the "data" envelope for a presence_stats Response,
+// mirroring the shape Centrifugo's HTTP API returns for the same command.
+type presenceStatsResult struct {
+	NumClients int32 `json:"num_clients"`
+	NumUsers   int32 `json:"num_users"`
+}
+
+func presenceStatsData(reply *apipb.PresenceStatsResponse) presenceStatsResult {
+	return presenceStatsResult{NumClients: reply.NumClients, NumUsers: reply.NumUsers}
+}
+
+func historyData(pubs []*apipb.Publication) []gocent.Message {
+	out := make([]gocent.Message, 0, len(pubs))
+	for _, p := range pubs {
+		out = append(out, gocent.Message{UID: p.Uid, Data: p.Data})
+	}
+	return out
+}
+
+func statsData(nodes []*apipb.NodeInfo) gocent.Stats {
+	out := make([]gocent.NodeInfo, 0, len(nodes))
+	for _, n := range nodes {
+		out = append(out, gocent.NodeInfo{
+			UID:         n.Uid,
+			NumClients:  n.NumClients,
+			NumUsers:    n.NumUsers,
+			NumChannels: n.NumChannels,
+			Uptime:      n.Uptime,
+		})
+	}
+	return gocent.Stats{Nodes: out}
+}
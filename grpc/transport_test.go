@@ -0,0 +1,290 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/scarbo87/gocent"
+	"github.com/scarbo87/gocent/grpc/apipb"
+	grpclib "google.golang.org/grpc"
+)
+
+// fakeCentrifugoApiClient implements apipb.CentrifugoApiClient, recording the
+// last request it saw per method so tests can assert routing without a real
+// gRPC server.
+type fakeCentrifugoApiClient struct {
+	apipb.CentrifugoApiClient
+
+	lastPublish       *apipb.PublishRequest
+	lastPresence      *apipb.PresenceRequest
+	lastPresenceStats *apipb.PresenceStatsRequest
+	lastHistory       *apipb.HistoryRequest
+	lastHistoryRemove *apipb.HistoryRemoveRequest
+	lastSubscribe     *apipb.SubscribeRequest
+	lastRefresh       *apipb.RefreshRequest
+	lastBatch         *apipb.BatchRequest
+
+	presenceResp      *apipb.PresenceResponse
+	presenceStatsResp *apipb.PresenceStatsResponse
+	historyResp       *apipb.HistoryResponse
+	batchResp         *apipb.BatchResponse
+}
+
+func (f *fakeCentrifugoApiClient) Publish(ctx context.Context, in *apipb.PublishRequest, opts ...grpclib.CallOption) (*apipb.PublishResponse, error) {
+	f.lastPublish = in
+	return &apipb.PublishResponse{}, nil
+}
+
+func (f *fakeCentrifugoApiClient) Presence(ctx context.Context, in *apipb.PresenceRequest, opts ...grpclib.CallOption) (*apipb.PresenceResponse, error) {
+	f.lastPresence = in
+	if f.presenceResp != nil {
+		return f.presenceResp, nil
+	}
+	return &apipb.PresenceResponse{}, nil
+}
+
+func (f *fakeCentrifugoApiClient) History(ctx context.Context, in *apipb.HistoryRequest, opts ...grpclib.CallOption) (*apipb.HistoryResponse, error) {
+	f.lastHistory = in
+	if f.historyResp != nil {
+		return f.historyResp, nil
+	}
+	return &apipb.HistoryResponse{}, nil
+}
+
+func (f *fakeCentrifugoApiClient) PresenceStats(ctx context.Context, in *apipb.PresenceStatsRequest, opts ...grpclib.CallOption) (*apipb.PresenceStatsResponse, error) {
+	f.lastPresenceStats = in
+	if f.presenceStatsResp != nil {
+		return f.presenceStatsResp, nil
+	}
+	return &apipb.PresenceStatsResponse{}, nil
+}
+
+func (f *fakeCentrifugoApiClient) HistoryRemove(ctx context.Context, in *apipb.HistoryRemoveRequest, opts ...grpclib.CallOption) (*apipb.HistoryRemoveResponse, error) {
+	f.lastHistoryRemove = in
+	return &apipb.HistoryRemoveResponse{}, nil
+}
+
+func (f *fakeCentrifugoApiClient) Subscribe(ctx context.Context, in *apipb.SubscribeRequest, opts ...grpclib.CallOption) (*apipb.SubscribeResponse, error) {
+	f.lastSubscribe = in
+	return &apipb.SubscribeResponse{}, nil
+}
+
+func (f *fakeCentrifugoApiClient) Refresh(ctx context.Context, in *apipb.RefreshRequest, opts ...grpclib.CallOption) (*apipb.RefreshResponse, error) {
+	f.lastRefresh = in
+	return &apipb.RefreshResponse{}, nil
+}
+
+func TestTransportDoRoutesPublish(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{}
+	transport := &Transport{client: fake}
+
+	cmds := []gocent.Command{{
+		UID:    "uid-1",
+		Method: "publish",
+		Params: map[string]interface{}{"channel": "news", "data": []byte(`{"text":"hi"}`)},
+	}}
+
+	if _, err := transport.Do(context.Background(), cmds); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if fake.lastPublish == nil {
+		t.Fatal("Publish was not called")
+	}
+	if fake.lastPublish.Channel != "news" || fake.lastPublish.Uid != "uid-1" {
+		t.Errorf("Publish request = %+v, want channel=news uid=uid-1", fake.lastPublish)
+	}
+}
+
+func TestTransportDoRoutesPresenceMap(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{
+		presenceResp: &apipb.PresenceResponse{
+			Presence: map[string]*apipb.ClientInfo{
+				"client-1": {User: "alice", Client: "client-1"},
+			},
+		},
+	}
+	transport := &Transport{client: fake}
+
+	result, err := transport.Do(context.Background(), []gocent.Command{{Method: "presence", Params: map[string]interface{}{"channel": "news"}}})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	var body struct {
+		Data map[string]gocent.ClientInfo `json:"data"`
+	}
+	if err := json.Unmarshal(result[0].Body, &body); err != nil {
+		t.Fatalf("unmarshaling response body: %v", err)
+	}
+	if body.Data["client-1"].User != "alice" {
+		t.Errorf("presence data = %+v, want client-1.User = alice", body.Data)
+	}
+}
+
+func TestTransportDoRoutesHistoryRepeated(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{
+		historyResp: &apipb.HistoryResponse{
+			Publications: []*apipb.Publication{
+				{Data: []byte(`"first"`), Uid: "uid-1"},
+				{Data: []byte(`"second"`), Uid: "uid-2"},
+			},
+		},
+	}
+	transport := &Transport{client: fake}
+
+	result, err := transport.Do(context.Background(), []gocent.Command{{Method: "history", Params: map[string]interface{}{"channel": "news"}}})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	messages, err := gocent.DecodeHistory(result[0].Body)
+	if err != nil {
+		t.Fatalf("decoding response body: %v", err)
+	}
+	if len(messages) != 2 || messages[0].UID != "uid-1" || messages[1].UID != "uid-2" {
+		t.Errorf("history data = %+v, want 2 messages with uid-1, uid-2", messages)
+	}
+}
+
+func TestTransportDoRoutesPresenceStats(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{
+		presenceStatsResp: &apipb.PresenceStatsResponse{NumClients: 3, NumUsers: 2},
+	}
+	transport := &Transport{client: fake}
+
+	result, err := transport.Do(context.Background(), []gocent.Command{{Method: "presence_stats", Params: map[string]interface{}{"channel": "news"}}})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if fake.lastPresenceStats == nil || fake.lastPresenceStats.Channel != "news" {
+		t.Fatalf("PresenceStats request = %+v, want channel=news", fake.lastPresenceStats)
+	}
+
+	var body struct {
+		Data struct {
+			NumClients int32 `json:"num_clients"`
+			NumUsers   int32 `json:"num_users"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(result[0].Body, &body); err != nil {
+		t.Fatalf("unmarshaling response body: %v", err)
+	}
+	if body.Data.NumClients != 3 || body.Data.NumUsers != 2 {
+		t.Errorf("presence_stats data = %+v, want num_clients=3 num_users=2", body.Data)
+	}
+}
+
+func TestTransportDoRoutesHistoryRemove(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{}
+	transport := &Transport{client: fake}
+
+	if _, err := transport.Do(context.Background(), []gocent.Command{{UID: "uid-1", Method: "history_remove", Params: map[string]interface{}{"channel": "news"}}}); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if fake.lastHistoryRemove == nil || fake.lastHistoryRemove.Channel != "news" || fake.lastHistoryRemove.Uid != "uid-1" {
+		t.Errorf("HistoryRemove request = %+v, want channel=news uid=uid-1", fake.lastHistoryRemove)
+	}
+}
+
+func TestTransportDoRoutesSubscribe(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{}
+	transport := &Transport{client: fake}
+
+	if _, err := transport.Do(context.Background(), []gocent.Command{{Method: "subscribe", Params: map[string]interface{}{"channel": "news", "user": "alice"}}}); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if fake.lastSubscribe == nil || fake.lastSubscribe.Channel != "news" || fake.lastSubscribe.User != "alice" {
+		t.Errorf("Subscribe request = %+v, want channel=news user=alice", fake.lastSubscribe)
+	}
+}
+
+func TestTransportDoRoutesRefresh(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{}
+	transport := &Transport{client: fake}
+
+	if _, err := transport.Do(context.Background(), []gocent.Command{{Method: "refresh", Params: map[string]interface{}{"user": "alice"}}}); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if fake.lastRefresh == nil || fake.lastRefresh.User != "alice" {
+		t.Errorf("Refresh request = %+v, want user=alice", fake.lastRefresh)
+	}
+}
+
+func (f *fakeCentrifugoApiClient) Batch(ctx context.Context, in *apipb.BatchRequest, opts ...grpclib.CallOption) (*apipb.BatchResponse, error) {
+	f.lastBatch = in
+	if f.batchResp != nil {
+		return f.batchResp, nil
+	}
+	resp := &apipb.BatchResponse{Results: make([]*apipb.ResultItem, len(in.Commands))}
+	for i := range in.Commands {
+		resp.Results[i] = &apipb.ResultItem{}
+	}
+	return resp, nil
+}
+
+func TestTransportDoBatchesMultipleCommands(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{
+		batchResp: &apipb.BatchResponse{
+			Results: []*apipb.ResultItem{
+				{Body: []byte(`{"data":"ok-1"}`)},
+				{Error: "boom"},
+			},
+		},
+	}
+	transport := &Transport{client: fake}
+
+	cmds := []gocent.Command{
+		{UID: "uid-1", Method: "publish", Params: map[string]interface{}{"channel": "news"}},
+		{UID: "uid-2", Method: "unsubscribe", Params: map[string]interface{}{"channel": "news"}},
+	}
+
+	result, err := transport.Do(context.Background(), cmds)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if fake.lastBatch == nil {
+		t.Fatal("Batch was not called")
+	}
+	if fake.lastPublish != nil {
+		t.Error("Publish should not be called for a multi-command Do, Batch should carry it instead")
+	}
+	if len(fake.lastBatch.Commands) != 2 {
+		t.Fatalf("Batch request carried %d commands, want 2", len(fake.lastBatch.Commands))
+	}
+	if fake.lastBatch.Commands[0].Method != "publish" || fake.lastBatch.Commands[0].Uid != "uid-1" {
+		t.Errorf("Batch command[0] = %+v, want method=publish uid=uid-1", fake.lastBatch.Commands[0])
+	}
+	if fake.lastBatch.Commands[1].Method != "unsubscribe" || fake.lastBatch.Commands[1].Uid != "uid-2" {
+		t.Errorf("Batch command[1] = %+v, want method=unsubscribe uid=uid-2", fake.lastBatch.Commands[1])
+	}
+
+	if len(result) != 2 || string(result[0].Body) != `{"data":"ok-1"}` || result[1].Error != "boom" {
+		t.Errorf("result = %+v, want body/error from Batch results in order", result)
+	}
+}
+
+func TestTransportDoBatchMalformedResponse(t *testing.T) {
+	fake := &fakeCentrifugoApiClient{batchResp: &apipb.BatchResponse{Results: []*apipb.ResultItem{{}}}}
+	transport := &Transport{client: fake}
+
+	cmds := []gocent.Command{
+		{Method: "publish", Params: map[string]interface{}{"channel": "news"}},
+		{Method: "publish", Params: map[string]interface{}{"channel": "other"}},
+	}
+
+	if _, err := transport.Do(context.Background(), cmds); err != gocent.ErrMalformedResponse {
+		t.Errorf("Do() error = %v, want ErrMalformedResponse", err)
+	}
+}
+
+func TestTransportDoUnknownMethod(t *testing.T) {
+	transport := &Transport{client: &fakeCentrifugoApiClient{}}
+
+	_, err := transport.Do(context.Background(), []gocent.Command{{Method: "bogus"}})
+	if err != ErrUnknownMethod {
+		t.Errorf("Do() error = %v, want ErrUnknownMethod", err)
+	}
+}
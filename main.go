@@ -39,10 +39,16 @@
 //  result, err := c.Send()
 //  println("Sent", len(result), "publish commands in one request")
 //
+// Every method on Client that talks to the server also has a Context variant (PublishContext, SendContext, ...)
+// that takes a context.Context as its first argument and uses it to cancel the
+// underlying HTTP request. The plain methods are thin wrappers around
+// context.Background() kept for backwards compatibility.
+//
 package gocent // import "github.com/scarbo87/gocent"
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -64,22 +70,101 @@ var (
 	ErrMalformedResponse = errors.New("malformed response returned from server")
 )
 
+// Transport abstracts the wire format used to deliver a batch of commands to
+// Centrifugo and read back a Result. The default Client uses httpTransport,
+// which POSTs signed JSON to the HTTP API endpoint, but any implementation
+// (for example a gRPC one) can be plugged in with Client.SetTransport.
+type Transport interface {
+	Do(ctx context.Context, cmds []Command) (Result, error)
+}
+
 // Client is API client for project registered in server.
 type Client struct {
 	Endpoint string
 	Secret   string
 	Timeout  time.Duration
 
-	mu       sync.RWMutex
-	muCmds       sync.RWMutex
-	cmds     []Command
-	insecure bool
-	client   *http.Client
+	// mu guards cmds, insecure, client and transport so that the command
+	// buffer and the transport used to flush it are always observed in a
+	// consistent state, whether they are touched from Add*/Send or from one
+	// of the one-shot helpers such as Publish.
+	mu          sync.Mutex
+	cmds        []Command
+	insecure    bool
+	client      *http.Client
+	transport   Transport
+	apiKey      string
+	retry       RetryPolicy
+	breaker     *breaker
+	requestHook func(*http.Request)
+}
+
+// ClientOption configures optional, advanced Client behaviour (retries,
+// circuit breaking, request tracing) without changing the existing
+// NewClient/NewInsecureAPIClient call sites.
+type ClientOption func(*Client)
+
+// WithRetry makes Send automatically retry failed requests according to
+// policy: network errors and 5xx/429 responses are retried with exponential
+// backoff and jitter, honoring a Retry-After header when the server sends one.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithBreaker wraps Send with a circuit breaker so that a downed Centrifugo
+// fails fast instead of adding retry latency to every caller.
+func WithBreaker(cfg BreakerConfig) ClientOption {
+	return func(c *Client) {
+		c.breaker = newBreaker(cfg)
+	}
+}
+
+// WithRequestHook registers a hook invoked with the outgoing *http.Request
+// right before it is sent, letting callers attach tracing headers/spans.
+func WithRequestHook(hook func(*http.Request)) ClientOption {
+	return func(c *Client) {
+		c.requestHook = hook
+	}
+}
+
+// WithAPIKey is the ClientOption equivalent of SetAPIKey, for callers who
+// want the apikey auth scheme selected at construction time alongside
+// WithRetry/WithBreaker/WithRequestHook instead of via a separate call.
+func WithAPIKey(key string) ClientOption {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// SetAPIKey switches the HTTP transport from signing requests with
+// X-API-Sign to sending "Authorization: apikey <key>", the auth scheme
+// Centrifugo v2+ expects. It has no effect once a non-default Transport has
+// been installed with SetTransport.
+func (c *Client) SetAPIKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.apiKey = key
+}
+
+// SetTransport replaces the transport used to deliver buffered commands to
+// the server. This is how callers switch a Client from the default HTTP/JSON
+// transport to an alternative one, such as the gRPC transport in the
+// gocent/grpc subpackage, without changing any other Client usage.
+func (c *Client) SetTransport(transport Transport) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.transport = transport
 }
 
 // NewClient returns initialized client instance based on provided server address,
-//project key, project secret, timeout and http.Transport settings
-func NewClient(addr, secret string, timeout time.Duration, transport *http.Transport) *Client {
+//project key, project secret, timeout and http.Transport settings. Advanced
+//behaviour (retries, circuit breaking, request tracing) can be enabled by
+//passing ClientOption values, e.g. NewClient(addr, secret, timeout, nil, WithRetry(policy)).
+func NewClient(addr, secret string, timeout time.Duration, transport *http.Transport, opts ...ClientOption) *Client {
 
 	addr = strings.TrimRight(addr, "/")
 	if !strings.HasSuffix(addr, "/api") {
@@ -88,7 +173,7 @@ func NewClient(addr, secret string, timeout time.Duration, transport *http.Trans
 
 	apiEndpoint := addr + "/"
 
-	return &Client{
+	c := &Client{
 		Endpoint: apiEndpoint,
 		Secret:   secret,
 		Timeout:  timeout,
@@ -99,11 +184,16 @@ func NewClient(addr, secret string, timeout time.Duration, transport *http.Trans
 			Timeout:   timeout,
 		},
 	}
+	c.transport = &httpTransport{client: c}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // NewInsecureAPIClient allows to create client that won't sign every HTTP API request.
 // This is useful when your Centrifugo /api/ endpoint protected by firewall.
-func NewInsecureAPIClient(addr string, timeout time.Duration, transport *http.Transport) *Client {
+func NewInsecureAPIClient(addr string, timeout time.Duration, transport *http.Transport, opts ...ClientOption) *Client {
 
 	addr = strings.TrimRight(addr, "/")
 	if !strings.HasSuffix(addr, "/api") {
@@ -112,7 +202,7 @@ func NewInsecureAPIClient(addr string, timeout time.Duration, transport *http.Tr
 
 	apiEndpoint := addr + "/"
 
-	return &Client{
+	c := &Client{
 		Endpoint: apiEndpoint,
 		Timeout:  timeout,
 		cmds:     []Command{},
@@ -123,6 +213,11 @@ func NewInsecureAPIClient(addr string, timeout time.Duration, transport *http.Tr
 			Timeout:   timeout,
 		},
 	}
+	c.transport = &httpTransport{client: c}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func getTransport(transport *http.Transport) *http.Transport {
@@ -149,8 +244,8 @@ func (c *Client) Reset() {
 // AddPublish adds publish command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddPublish(channel string, data []byte) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	var raw json.RawMessage
 	raw = json.RawMessage(data)
@@ -167,8 +262,8 @@ func (c *Client) AddPublish(channel string, data []byte) error {
 // AddPublishClient adds publish command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddPublishClient(channel string, data []byte, client string) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	var raw json.RawMessage
 	raw = json.RawMessage(data)
@@ -186,8 +281,8 @@ func (c *Client) AddPublishClient(channel string, data []byte, client string) er
 // AddBroadcast adds broadcast command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddBroadcast(channels []string, data []byte) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	var raw json.RawMessage
 	raw = json.RawMessage(data)
@@ -204,8 +299,8 @@ func (c *Client) AddBroadcast(channels []string, data []byte) error {
 // AddBroadcastClient adds broadcast command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddBroadcastClient(channels []string, data []byte, client string) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	var raw json.RawMessage
 	raw = json.RawMessage(data)
@@ -223,8 +318,8 @@ func (c *Client) AddBroadcastClient(channels []string, data []byte, client strin
 // AddUnsubscribe adds unsubscribe command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddUnsubscribe(channel string, user string) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	cmd := Command{
 		Method: "unsubscribe",
@@ -239,8 +334,8 @@ func (c *Client) AddUnsubscribe(channel string, user string) error {
 // AddDisconnect adds disconnect command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddDisconnect(user string) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	cmd := Command{
 		Method: "disconnect",
@@ -254,8 +349,8 @@ func (c *Client) AddDisconnect(user string) error {
 // AddPresence adds presence command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddPresence(channel string) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	cmd := Command{
 		Method: "presence",
@@ -269,8 +364,8 @@ func (c *Client) AddPresence(channel string) error {
 // AddHistory adds history command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddHistory(channel string) error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	cmd := Command{
 		Method: "history",
@@ -284,8 +379,8 @@ func (c *Client) AddHistory(channel string) error {
 // AddChannels adds channels command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddChannels() error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	cmd := Command{
 		Method: "channels",
@@ -297,8 +392,8 @@ func (c *Client) AddChannels() error {
 // AddStats adds stats command to client command buffer but not actually
 // send it until Send method explicitly called.
 func (c *Client) AddStats() error {
-	c.muCmds.Lock()
-	defer c.muCmds.Unlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	cmd := Command{
 		Method: "stats",
@@ -307,22 +402,51 @@ func (c *Client) AddStats() error {
 	return c.add(cmd)
 }
 
+// addAndFlush adds cmd as the sole entry in an otherwise-empty command
+// buffer and sends it immediately. It is the shared implementation behind
+// the one-shot helpers (Publish, Presence, History, ...): it snapshots and
+// clears the buffer while holding c.mu, then performs the request through
+// the transport with the lock released, so a slow server or retry backoff
+// never blocks concurrent Add*/Publish/Send callers.
+func (c *Client) addAndFlush(ctx context.Context, cmd Command) (Result, error) {
+	c.mu.Lock()
+	if len(c.cmds) != 0 {
+		c.mu.Unlock()
+		return Result{}, ErrClientNotEmpty
+	}
+	if err := c.add(cmd); err != nil {
+		c.mu.Unlock()
+		return Result{}, err
+	}
+	cmds := c.cmds
+	c.cmds = []Command{}
+	transport := c.transport
+	c.mu.Unlock()
+
+	result, _, err := c.doSend(ctx, transport, cmds)
+	return result, err
+}
+
 // Publish sends publish command to server and returns boolean indicator of success and
 // any error occurred in process.
 func (c *Client) Publish(channel string, data []byte) (bool, error) {
-	if !c.empty() {
-		return false, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.PublishContext(context.Background(), channel, data)
+}
 
-	err := c.AddPublish(channel, data)
-	if err != nil {
-		return false, err
+// PublishContext is like Publish but takes a context.Context to control cancellation
+// and deadlines of the underlying HTTP request.
+func (c *Client) PublishContext(ctx context.Context, channel string, data []byte) (bool, error) {
+	var raw json.RawMessage
+	raw = json.RawMessage(data)
+	cmd := Command{
+		Method: "publish",
+		Params: map[string]interface{}{
+			"channel": channel,
+			"data":    &raw,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return false, err
 	}
@@ -338,19 +462,24 @@ func (c *Client) Publish(channel string, data []byte) (bool, error) {
 // PublishClient sends publish command to server and returns boolean indicator of success and
 // any error occurred in process. `client` is client ID initiating this event.
 func (c *Client) PublishClient(channel string, data []byte, client string) (bool, error) {
-	if !c.empty() {
-		return false, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.PublishClientContext(context.Background(), channel, data, client)
+}
 
-	err := c.AddPublishClient(channel, data, client)
-	if err != nil {
-		return false, err
+// PublishClientContext is like PublishClient but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) PublishClientContext(ctx context.Context, channel string, data []byte, client string) (bool, error) {
+	var raw json.RawMessage
+	raw = json.RawMessage(data)
+	cmd := Command{
+		Method: "publish",
+		Params: map[string]interface{}{
+			"channel": channel,
+			"data":    &raw,
+			"client":  client,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return false, err
 	}
@@ -365,19 +494,23 @@ func (c *Client) PublishClient(channel string, data []byte, client string) (bool
 
 // Broadcast sends broadcast command to server.
 func (c *Client) Broadcast(channels []string, data []byte) (bool, error) {
-	if !c.empty() {
-		return false, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.BroadcastContext(context.Background(), channels, data)
+}
 
-	err := c.AddBroadcast(channels, data)
-	if err != nil {
-		return false, err
+// BroadcastContext is like Broadcast but takes a context.Context to control cancellation
+// and deadlines of the underlying HTTP request.
+func (c *Client) BroadcastContext(ctx context.Context, channels []string, data []byte) (bool, error) {
+	var raw json.RawMessage
+	raw = json.RawMessage(data)
+	cmd := Command{
+		Method: "broadcast",
+		Params: map[string]interface{}{
+			"channels": channels,
+			"data":     &raw,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return false, err
 	}
@@ -390,19 +523,24 @@ func (c *Client) Broadcast(channels []string, data []byte) (bool, error) {
 
 // BroadcastClient sends broadcast command to server with client ID.
 func (c *Client) BroadcastClient(channels []string, data []byte, client string) (bool, error) {
-	if !c.empty() {
-		return false, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.BroadcastClientContext(context.Background(), channels, data, client)
+}
 
-	err := c.AddBroadcastClient(channels, data, client)
-	if err != nil {
-		return false, err
+// BroadcastClientContext is like BroadcastClient but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) BroadcastClientContext(ctx context.Context, channels []string, data []byte, client string) (bool, error) {
+	var raw json.RawMessage
+	raw = json.RawMessage(data)
+	cmd := Command{
+		Method: "broadcast",
+		Params: map[string]interface{}{
+			"channels": channels,
+			"data":     &raw,
+			"client":   client,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return false, err
 	}
@@ -416,19 +554,21 @@ func (c *Client) BroadcastClient(channels []string, data []byte, client string)
 // Unsubscribe sends unsubscribe command to server and returns boolean indicator of success and
 // any error occurred in process.
 func (c *Client) Unsubscribe(channel, user string) (bool, error) {
-	if !c.empty() {
-		return false, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.UnsubscribeContext(context.Background(), channel, user)
+}
 
-	err := c.AddUnsubscribe(channel, user)
-	if err != nil {
-		return false, err
+// UnsubscribeContext is like Unsubscribe but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) UnsubscribeContext(ctx context.Context, channel, user string) (bool, error) {
+	cmd := Command{
+		Method: "unsubscribe",
+		Params: map[string]interface{}{
+			"channel": channel,
+			"user":    user,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return false, err
 	}
@@ -442,19 +582,20 @@ func (c *Client) Unsubscribe(channel, user string) (bool, error) {
 // Disconnect sends disconnect command to server and returns boolean indicator of success and
 // any error occurred in process.
 func (c *Client) Disconnect(user string) (bool, error) {
-	if !c.empty() {
-		return false, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.DisconnectContext(context.Background(), user)
+}
 
-	err := c.AddDisconnect(user)
-	if err != nil {
-		return false, err
+// DisconnectContext is like Disconnect but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) DisconnectContext(ctx context.Context, user string) (bool, error) {
+	cmd := Command{
+		Method: "disconnect",
+		Params: map[string]interface{}{
+			"user": user,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return false, err
 	}
@@ -468,19 +609,20 @@ func (c *Client) Disconnect(user string) (bool, error) {
 // Presence sends presence command for channel to server and returns map with client
 // information and any error occurred in process.
 func (c *Client) Presence(channel string) (map[string]ClientInfo, error) {
-	if !c.empty() {
-		return map[string]ClientInfo{}, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.PresenceContext(context.Background(), channel)
+}
 
-	err := c.AddPresence(channel)
-	if err != nil {
-		return map[string]ClientInfo{}, err
+// PresenceContext is like Presence but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) PresenceContext(ctx context.Context, channel string) (map[string]ClientInfo, error) {
+	cmd := Command{
+		Method: "presence",
+		Params: map[string]interface{}{
+			"channel": channel,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return map[string]ClientInfo{}, err
 	}
@@ -494,19 +636,20 @@ func (c *Client) Presence(channel string) (map[string]ClientInfo, error) {
 // History sends history command for channel to server and returns slice with
 // messages and any error occurred in process.
 func (c *Client) History(channel string) ([]Message, error) {
-	if !c.empty() {
-		return []Message{}, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.HistoryContext(context.Background(), channel)
+}
 
-	err := c.AddHistory(channel)
-	if err != nil {
-		return []Message{}, err
+// HistoryContext is like History but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) HistoryContext(ctx context.Context, channel string) ([]Message, error) {
+	cmd := Command{
+		Method: "history",
+		Params: map[string]interface{}{
+			"channel": channel,
+		},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return []Message{}, err
 	}
@@ -520,19 +663,18 @@ func (c *Client) History(channel string) ([]Message, error) {
 // Channels sends channels command to server and returns slice with
 // active channels (with one or more subscribers).
 func (c *Client) Channels() ([]string, error) {
-	if !c.empty() {
-		return []string{}, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.ChannelsContext(context.Background())
+}
 
-	err := c.AddChannels()
-	if err != nil {
-		return []string{}, err
+// ChannelsContext is like Channels but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) ChannelsContext(ctx context.Context) ([]string, error) {
+	cmd := Command{
+		Method: "channels",
+		Params: map[string]interface{}{},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return []string{}, err
 	}
@@ -545,19 +687,18 @@ func (c *Client) Channels() ([]string, error) {
 
 // Stats sends stats command to server and returns Stats.
 func (c *Client) Stats() (Stats, error) {
-	if !c.empty() {
-		return Stats{}, ErrClientNotEmpty
-	}
-
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.StatsContext(context.Background())
+}
 
-	err := c.AddStats()
-	if err != nil {
-		return Stats{}, err
+// StatsContext is like Stats but takes a context.Context to control
+// cancellation and deadlines of the underlying HTTP request.
+func (c *Client) StatsContext(ctx context.Context) (Stats, error) {
+	cmd := Command{
+		Method: "stats",
+		Params: map[string]interface{}{},
 	}
 
-	result, err := c.Send()
+	result, err := c.addAndFlush(ctx, cmd)
 	if err != nil {
 		return Stats{}, err
 	}
@@ -640,55 +781,176 @@ func DecodePresence(body []byte) (map[string]ClientInfo, error) {
 // one request. Using this method you should manually decode all responses in
 // returned Result.
 func (c *Client) Send() (Result, error) {
+	return c.SendContext(context.Background())
+}
+
+// SendContext is like Send but takes a context.Context so that callers can cancel
+// or bound the underlying HTTP request instead of relying solely on the
+// constructor-level Timeout.
+func (c *Client) SendContext(ctx context.Context) (Result, error) {
+	result, _, err := c.flush(ctx)
+	return result, err
+}
+
+// SendWithUIDs behaves like Send but also returns the same responses keyed
+// by the UID add() assigned each command, so callers that built the buffer
+// with AddPublish/AddBroadcast/... can correlate replies without relying on
+// slice position.
+func (c *Client) SendWithUIDs() (Result, map[string]Response, error) {
+	return c.SendWithUIDsContext(context.Background())
+}
+
+// SendWithUIDsContext is like SendWithUIDs but takes a context.Context to
+// control cancellation and deadlines of the underlying request.
+func (c *Client) SendWithUIDsContext(ctx context.Context) (Result, map[string]Response, error) {
+	return c.flush(ctx)
+}
+
+// flush snapshots and clears the command buffer while holding c.mu, then
+// performs the request through the configured transport with the lock
+// released, so that retry backoff or a slow/downed server cannot block
+// concurrent Add*/Publish/Send callers.
+func (c *Client) flush(ctx context.Context) (Result, map[string]Response, error) {
+	c.mu.Lock()
 	cmds := c.cmds
 	c.cmds = []Command{}
+	transport := c.transport
+	c.mu.Unlock()
 
-	result, err := c.send(cmds)
+	return c.doSend(ctx, transport, cmds)
+}
+
+// doSend performs the request for cmds through transport, the value of
+// c.transport snapshotted under c.mu by the caller alongside cmds: c.transport
+// can be replaced concurrently by SetTransport, so doSend itself must not
+// read c.transport directly, and must not be called while holding c.mu.
+func (c *Client) doSend(ctx context.Context, transport Transport, cmds []Command) (Result, map[string]Response, error) {
+	result, err := transport.Do(ctx, cmds)
 	if err != nil {
-		return Result{}, err
+		return Result{}, nil, err
 	}
 
 	if len(result) != len(cmds) {
-		return Result{}, ErrMalformedResponse
+		return Result{}, nil, ErrMalformedResponse
+	}
+
+	byUID := make(map[string]Response, len(cmds))
+	for i, cmd := range cmds {
+		byUID[cmd.UID] = result[i]
 	}
 
-	return result, nil
+	return result, byUID, nil
+}
+
+// httpTransport is the default Transport, sending commands as signed JSON to
+// Centrifugo's HTTP API endpoint. When the owning Client was built with
+// WithRetry and/or WithBreaker it also applies retry-with-backoff and
+// circuit breaking around the POST.
+type httpTransport struct {
+	client *Client
 }
 
-func (c *Client) send(cmds []Command) (Result, error) {
+// Do implements Transport.
+func (t *httpTransport) Do(ctx context.Context, cmds []Command) (Result, error) {
+	c := t.client
+
+	if !c.breaker.allow() {
+		return Result{}, ErrBreakerOpen
+	}
+
+	// apiKey is snapshotted once here, under c.mu, rather than read directly
+	// by do() on every attempt: SetAPIKey can be called concurrently with an
+	// in-flight Send, and do() runs outside c.mu for the whole retry loop.
+	c.mu.Lock()
+	apiKey := c.apiKey
+	c.mu.Unlock()
+
+	attempts := c.retry.maxAttempts()
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result, resp, err := t.do(ctx, apiKey, cmds)
+
+		if err == nil && resp.StatusCode == http.StatusOK {
+			c.breaker.recordSuccess()
+			return result, nil
+		}
+
+		if err == nil {
+			lastErr = errors.New("wrong status code: " + resp.Status)
+		} else {
+			lastErr = err
+		}
+
+		retryable := err != nil || retryableStatus(resp.StatusCode)
+		if retryable {
+			c.breaker.recordFailure()
+		} else {
+			// The server answered, so it is up: resolve a half-open probe.
+			c.breaker.recordSuccess()
+		}
+		if !retryable || attempt == attempts {
+			break
+		}
+
+		select {
+		case <-time.After(c.retry.delay(attempt, resp)):
+		case <-ctx.Done():
+			return Result{}, ctx.Err()
+		}
+	}
+
+	return Result{}, lastErr
+}
+
+// do performs a single HTTP attempt, returning the decoded Result and the
+// raw *http.Response (so the caller can inspect status/Retry-After) whenever
+// the request reached the server.
+func (t *httpTransport) do(ctx context.Context, apiKey string, cmds []Command) (Result, *http.Response, error) {
+	c := t.client
+
 	data, err := json.Marshal(cmds)
 	if err != nil {
-		return Result{}, err
+		return Result{}, nil, err
 	}
 
 	r, err := http.NewRequest("POST", c.Endpoint, bytes.NewBuffer(data))
 	if err != nil {
-		return Result{}, err
+		return Result{}, nil, err
 	}
+	r = r.WithContext(ctx)
 
-	if !c.insecure {
+	if apiKey != "" {
+		r.Header.Set("Authorization", "apikey "+apiKey)
+	} else if !c.insecure {
 		r.Header.Set("X-API-Sign", GenerateAPISign(c.Secret, data))
 	}
 	r.Header.Set("Content-Type", "application/json")
 
+	if c.requestHook != nil {
+		c.requestHook(r)
+	}
+
 	resp, err := c.client.Do(r)
 	if err != nil {
-		return Result{}, err
+		return Result{}, nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return Result{}, errors.New("wrong status code: " + resp.Status)
+		return Result{}, resp, nil
 	}
 
 	var result Result
 	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Result{}, resp, err
+	}
 	err = json.Unmarshal(body, &result)
 
-	return result, err
+	return result, resp, err
 }
 
-// Lock must be held outside this method.
+// add appends cmd to the command buffer. The caller must hold c.mu.
 // Todo: in new version uuid return error
 func (c *Client) add(cmd Command) error {
 	cmd.UID = uuid.NewV4().String()
@@ -696,15 +958,12 @@ func (c *Client) add(cmd Command) error {
 	return nil
 }
 
-func (c *Client) empty() bool {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return len(c.cmds) == 0
-}
-
 // GenerateClientToken generates client token based on secret key and provided
 // connection parameters such as user ID, timestamp and info JSON string.
+//
+// Deprecated: this is the Centrifugo v1 HMAC-concatenation connection token
+// scheme. Centrifugo v2 and newer only accept HS256/RS256 JWTs; use
+// GenerateConnectionTokenHS256 or GenerateConnectionTokenRS256 instead.
 func GenerateClientToken(secret, user, timestamp, info string) string {
 	token := hmac.New(sha256.New, []byte(secret))
 	token.Write([]byte(user))
@@ -722,6 +981,10 @@ func GenerateAPISign(secret string, data []byte) string {
 
 // GenerateChannelSign generates sign which is used to prove permission of
 // client to subscribe on private channel.
+//
+// Deprecated: this is the Centrifugo v1 HMAC-concatenation channel sign
+// scheme. Centrifugo v2 and newer only accept HS256/RS256 JWTs; use
+// GenerateSubscribeTokenHS256 or GenerateSubscribeTokenRS256 instead.
 func GenerateChannelSign(secret, client, channel, channelData string) string {
 	sign := hmac.New(sha256.New, []byte(secret))
 	sign.Write([]byte(client))
@@ -0,0 +1,201 @@
+package gocent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingTransport is a fake Transport that records how many commands it
+// was asked to deliver, for tests that want to verify nothing is lost or
+// duplicated across concurrent Client callers rather than exercising the
+// wire format.
+type countingTransport struct {
+	onDo func(n int)
+}
+
+func (t *countingTransport) Do(ctx context.Context, cmds []Command) (Result, error) {
+	t.onDo(len(cmds))
+	result := make(Result, len(cmds))
+	for i := range cmds {
+		result[i] = Response{}
+	}
+	return result, nil
+}
+
+// TestClientConcurrentAddSendSetTransport drives AddPublish, SendContext and
+// SetTransport from many goroutines at once, the combination chunk0-1's mu
+// consolidation was meant to make safe (cmds, transport and client were
+// previously split across two mutexes, so a goroutine could observe one
+// locked value alongside a stale read of the other). Run with -race to catch
+// a regression back to that; the count assertion below also catches a
+// transport ending up with a different number of commands than were added,
+// which a broken flush/SetTransport interleaving could cause independently
+// of what the race detector flags.
+func TestClientConcurrentAddSendSetTransport(t *testing.T) {
+	var totalSeen int64
+	onDo := func(n int) { atomic.AddInt64(&totalSeen, int64(n)) }
+	transportA := &countingTransport{onDo: onDo}
+	transportB := &countingTransport{onDo: onDo}
+
+	c := NewClient("http://localhost:8000", "secret", time.Second, nil)
+	c.SetTransport(transportA)
+
+	const goroutines = 20
+	const perGoroutine = 50
+	var totalAdded int64
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				if err := c.AddPublish("news", []byte("{}")); err != nil {
+					t.Errorf("AddPublish() error = %v", err)
+					return
+				}
+				atomic.AddInt64(&totalAdded, 1)
+
+				if j%2 == 0 {
+					c.SetTransport(transportA)
+				} else {
+					c.SetTransport(transportB)
+				}
+
+				if _, err := c.SendContext(context.Background()); err != nil {
+					t.Errorf("SendContext() error = %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Whatever didn't get flushed by its own goroutine's Send racing with
+	// another's is still sitting in the buffer.
+	if _, err := c.SendContext(context.Background()); err != nil {
+		t.Fatalf("final SendContext() error = %v", err)
+	}
+
+	if got, want := atomic.LoadInt64(&totalSeen), atomic.LoadInt64(&totalAdded); got != want {
+		t.Errorf("transports saw %d commands total, want %d (commands lost or duplicated across concurrent Add/Send/SetTransport)", got, want)
+	}
+}
+
+// TestHTTPTransportRetriesOn5xx exercises httpTransport.Do's retry-on-5xx
+// path, which had no coverage beyond RetryPolicy's standalone unit tests.
+func TestHTTPTransportRetriesOn5xx(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`[{}]`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "secret", time.Second, nil, WithRetry(RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}))
+	if err := c.AddPublish("news", []byte("{}")); err != nil {
+		t.Fatalf("AddPublish() error = %v", err)
+	}
+
+	if _, err := c.Send(); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 3 {
+		t.Errorf("server saw %d requests, want 3 (2 failures + 1 success)", got)
+	}
+}
+
+// TestHTTPTransportHonorsRetryAfter checks that a 429 carrying a Retry-After
+// header is retried and eventually succeeds, rather than being treated as a
+// non-retryable failure.
+func TestHTTPTransportHonorsRetryAfter(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`[{}]`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "secret", time.Second, nil, WithRetry(RetryPolicy{
+		MaxAttempts: 2,
+		BaseDelay:   time.Second, // would blow the test's deadline if Retry-After isn't honored
+		MaxDelay:    time.Second,
+	}))
+	if err := c.AddPublish("news", []byte("{}")); err != nil {
+		t.Fatalf("AddPublish() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.Send()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Send() did not return within 500ms; Retry-After was not honored over BaseDelay")
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Errorf("server saw %d requests, want 2 (1 retryable failure + 1 success)", got)
+	}
+}
+
+// TestHTTPTransportBreakerTripsAfterThreshold exercises httpTransport.Do's
+// breaker integration: once Threshold consecutive failures have tripped it,
+// further Sends must fail fast with ErrBreakerOpen instead of reaching the
+// server.
+func TestHTTPTransportBreakerTripsAfterThreshold(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL, "secret", time.Second, nil,
+		WithRetry(RetryPolicy{MaxAttempts: 1}),
+		WithBreaker(BreakerConfig{Threshold: 2, Window: time.Minute, OpenTimeout: time.Minute}),
+	)
+
+	for i := 0; i < 2; i++ {
+		if err := c.AddPublish("news", []byte("{}")); err != nil {
+			t.Fatalf("AddPublish() error = %v", err)
+		}
+		if _, err := c.Send(); err == nil {
+			t.Fatalf("Send() #%d error = nil, want a failure from the 500 response", i+1)
+		}
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("server saw %d requests before breaker should have tripped, want 2", got)
+	}
+
+	if err := c.AddPublish("news", []byte("{}")); err != nil {
+		t.Fatalf("AddPublish() error = %v", err)
+	}
+	if _, err := c.Send(); err != ErrBreakerOpen {
+		t.Errorf("Send() after tripping error = %v, want ErrBreakerOpen", err)
+	}
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Errorf("server saw %d requests, want still 2 (breaker should fail fast without reaching the server)", got)
+	}
+}
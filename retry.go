@@ -0,0 +1,62 @@
+package gocent
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures automatic retry of failed Send requests. The zero
+// value disables retries (MaxAttempts 0 means "try once, don't retry").
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first
+	// one. A value <= 1 means no retries.
+	MaxAttempts int
+	// BaseDelay is the upper bound of the jittered delay before the first
+	// retry; each subsequent retry doubles that bound, up to MaxDelay.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff delay.
+	MaxDelay time.Duration
+}
+
+func (p RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay returns how long to wait before attempt (1-indexed: the delay before
+// attempt 2, 3, ...), with full jitter, unless resp carries a Retry-After
+// header, in which case that takes precedence.
+func (p RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if ra := resp.Header.Get("Retry-After"); ra != "" {
+			if secs, err := strconv.Atoi(ra); err == nil {
+				return time.Duration(secs) * time.Second
+			}
+		}
+	}
+
+	base := p.BaseDelay
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Second
+	}
+
+	d := base << uint(attempt-1)
+	if d <= 0 || d > maxDelay {
+		d = maxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// retryableStatus reports whether an HTTP response status is worth retrying:
+// any 5xx or 429 (Too Many Requests).
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
@@ -0,0 +1,80 @@
+package gocent
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name string
+		p    RetryPolicy
+		want int
+	}{
+		{"zero value disables retries", RetryPolicy{}, 1},
+		{"one attempt disables retries", RetryPolicy{MaxAttempts: 1}, 1},
+		{"negative treated as disabled", RetryPolicy{MaxAttempts: -1}, 1},
+		{"explicit attempts honored", RetryPolicy{MaxAttempts: 5}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.maxAttempts(); got != tt.want {
+				t.Errorf("maxAttempts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayRetryAfter(t *testing.T) {
+	p := RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"2"}}}
+
+	got := p.delay(1, resp)
+	if got != 2*time.Second {
+		t.Errorf("delay() = %v, want %v", got, 2*time.Second)
+	}
+}
+
+func TestRetryPolicyDelayBackoffBounds(t *testing.T) {
+	tests := []struct {
+		name     string
+		p        RetryPolicy
+		attempt  int
+		wantUpTo time.Duration
+	}{
+		{"defaults, first retry", RetryPolicy{}, 1, 100 * time.Millisecond},
+		{"defaults, third retry", RetryPolicy{}, 3, 400 * time.Millisecond},
+		{"exponential growth capped at MaxDelay", RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, 10, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				got := tt.p.delay(tt.attempt, nil)
+				if got < 0 || got > tt.wantUpTo {
+					t.Fatalf("delay() = %v, want in [0, %v]", got, tt.wantUpTo)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryableStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{599, true},
+	}
+	for _, tt := range tests {
+		if got := retryableStatus(tt.code); got != tt.want {
+			t.Errorf("retryableStatus(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
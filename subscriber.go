@@ -0,0 +1,675 @@
+package gocent
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	json "github.com/json-iterator/go"
+	"github.com/satori/go.uuid"
+)
+
+// ErrSubscriberClosed is returned by Subscriber methods once Close has been
+// called.
+var ErrSubscriberClosed = errors.New("gocent: subscriber closed")
+
+// ErrTimeout is returned when a request/reply round trip (subscribe,
+// unsubscribe, ...) does not get a server reply within SubscriberConfig.ReadTimeout.
+var ErrTimeout = errors.New("gocent: timeout waiting for server reply")
+
+// ErrNotConnected is returned by Subscribe/Unsubscribe/Publish when called
+// before Connect has established a connection.
+var ErrNotConnected = errors.New("gocent: subscriber not connected")
+
+// ConnectHandler is called once the Subscriber has established and
+// authenticated a connection to Centrifugo.
+type ConnectHandler func(*Subscriber)
+
+// DisconnectHandler is called when the connection to Centrifugo is lost,
+// before Subscriber starts reconnecting.
+type DisconnectHandler func(*Subscriber, error)
+
+// MessageHandler is called for every message published to a subscribed
+// channel.
+type MessageHandler func(channel string, msg Message)
+
+// JoinHandler is called when a client joins a subscribed channel.
+type JoinHandler func(channel string, info ClientInfo)
+
+// LeaveHandler is called when a client leaves a subscribed channel.
+type LeaveHandler func(channel string, info ClientInfo)
+
+// SubscribeSuccessHandler is called once a channel subscription is confirmed
+// by the server.
+type SubscribeSuccessHandler func(channel string)
+
+// SubscribeErrorHandler is called when the server rejects a channel
+// subscription.
+type SubscribeErrorHandler func(channel string, err error)
+
+// UnsubscribeHandler is called once a channel has been unsubscribed from,
+// either explicitly or because the connection dropped.
+type UnsubscribeHandler func(channel string)
+
+// RefreshHandler is called when the current connection token is about to
+// expire; it must return a new one.
+type RefreshHandler func() (token string, err error)
+
+// PrivateSubHandler is called when subscribing to a private channel
+// (prefixed with "$"); it must return the channel sign for client/channel.
+type PrivateSubHandler func(channel string) (sign string, err error)
+
+// SubscriberConfig configures a Subscriber.
+type SubscriberConfig struct {
+	// WSURL is the Centrifugo WebSocket connection endpoint, e.g.
+	// "ws://localhost:8000/connection/websocket".
+	WSURL string
+	// User, Timestamp and Info are the connection parameters signed by
+	// Token (see GenerateClientToken / GenerateConnectionTokenHS256).
+	User      string
+	Timestamp string
+	Info      []byte
+	Token     string
+
+	// PingInterval is how often the Subscriber sends a ping frame to keep
+	// the connection alive. Defaults to 25 seconds.
+	PingInterval time.Duration
+	// ReadTimeout bounds how long a request/reply round trip may take.
+	// Defaults to 5 seconds.
+	ReadTimeout time.Duration
+	// ReconnectMinDelay and ReconnectMaxDelay bound the exponential backoff
+	// used between reconnect attempts. Default to 200ms and 20s.
+	ReconnectMinDelay time.Duration
+	ReconnectMaxDelay time.Duration
+}
+
+func (c *SubscriberConfig) withDefaults() SubscriberConfig {
+	cfg := *c
+	if cfg.PingInterval == 0 {
+		cfg.PingInterval = 25 * time.Second
+	}
+	if cfg.ReadTimeout == 0 {
+		cfg.ReadTimeout = 5 * time.Second
+	}
+	if cfg.ReconnectMinDelay == 0 {
+		cfg.ReconnectMinDelay = 200 * time.Millisecond
+	}
+	if cfg.ReconnectMaxDelay == 0 {
+		cfg.ReconnectMaxDelay = 20 * time.Second
+	}
+	return cfg
+}
+
+// subscription tracks per-channel state needed for message recovery and
+// handler dispatch.
+type subscription struct {
+	channel    string
+	private    bool
+	lastUID    string
+	subscribed bool
+}
+
+// connState bundles a single WebSocket connection with the pumps that serve
+// it. done is closed exactly once, by whichever of readPump/writePump/pingPump/
+// Close notices the connection has ended (or by dialAndAuth when it replaces
+// the connection), and tells the other pumps (bound to this
+// connState, not to whatever s.cs is current by the time they wake up) to
+// exit instead of leaking or acting on a connection that has moved on.
+type connState struct {
+	conn    *websocket.Conn
+	writeCh chan []byte
+	done    chan struct{}
+
+	stopOnce sync.Once
+}
+
+func newConnState(conn *websocket.Conn) *connState {
+	return &connState{
+		conn:    conn,
+		writeCh: make(chan []byte, 64),
+		done:    make(chan struct{}),
+	}
+}
+
+// stop signals this connection's pumps to exit. Safe to call more than once
+// and from multiple goroutines.
+func (cs *connState) stop() {
+	cs.stopOnce.Do(func() { close(cs.done) })
+}
+
+// Subscriber is a real-time client for Centrifugo: it opens a WebSocket
+// connection, authenticates, subscribes to channels and dispatches incoming
+// messages to the registered handlers. Unlike Client it is long-lived and
+// receives data instead of only publishing/inspecting it.
+//
+// Only the WebSocket transport is implemented. SockJS fallback (for
+// environments where raw WebSocket connections are unavailable) is out of
+// scope for this client.
+type Subscriber struct {
+	config SubscriberConfig
+
+	mu            sync.Mutex
+	cs            *connState
+	closed        bool
+	channels      map[string]*subscription
+	pendingReply  map[string]chan rawReply
+	reconnectWait time.Duration
+
+	onConnect          ConnectHandler
+	onDisconnect       DisconnectHandler
+	onMessage          MessageHandler
+	onJoin             JoinHandler
+	onLeave            LeaveHandler
+	onSubscribeSuccess SubscribeSuccessHandler
+	onSubscribeError   SubscribeErrorHandler
+	onUnsubscribe      UnsubscribeHandler
+	onRefresh          RefreshHandler
+	onPrivateSub       PrivateSubHandler
+}
+
+// clientCommand is a single frame sent to the server over the WebSocket
+// connection, correlated with the reply by UID the same way Client.Send
+// correlates batched HTTP commands.
+type clientCommand struct {
+	UID    string      `json:"uid"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+type rawReply struct {
+	UID    string          `json:"uid"`
+	Error  string          `json:"error,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// NewSubscriber creates a Subscriber from config. Call Connect to open the
+// connection; handlers can be registered with the On* methods before or
+// after Connect.
+func NewSubscriber(config SubscriberConfig) *Subscriber {
+	return &Subscriber{
+		config:   config.withDefaults(),
+		channels: make(map[string]*subscription),
+	}
+}
+
+// OnConnect registers h to be called after every successful (re)connect.
+// h can be registered before or after Connect, and is safe to set
+// concurrently with a running connection.
+func (s *Subscriber) OnConnect(h ConnectHandler) {
+	s.mu.Lock()
+	s.onConnect = h
+	s.mu.Unlock()
+}
+
+// OnDisconnect registers h to be called whenever the connection drops.
+func (s *Subscriber) OnDisconnect(h DisconnectHandler) {
+	s.mu.Lock()
+	s.onDisconnect = h
+	s.mu.Unlock()
+}
+
+// OnMessage registers h to be called for every message on a subscribed channel.
+func (s *Subscriber) OnMessage(h MessageHandler) {
+	s.mu.Lock()
+	s.onMessage = h
+	s.mu.Unlock()
+}
+
+// OnJoin registers h to be called when a client joins a subscribed channel.
+func (s *Subscriber) OnJoin(h JoinHandler) {
+	s.mu.Lock()
+	s.onJoin = h
+	s.mu.Unlock()
+}
+
+// OnLeave registers h to be called when a client leaves a subscribed channel.
+func (s *Subscriber) OnLeave(h LeaveHandler) {
+	s.mu.Lock()
+	s.onLeave = h
+	s.mu.Unlock()
+}
+
+// OnSubscribeSuccess registers h to be called when a subscription is confirmed.
+func (s *Subscriber) OnSubscribeSuccess(h SubscribeSuccessHandler) {
+	s.mu.Lock()
+	s.onSubscribeSuccess = h
+	s.mu.Unlock()
+}
+
+// OnSubscribeError registers h to be called when a subscription is rejected.
+func (s *Subscriber) OnSubscribeError(h SubscribeErrorHandler) {
+	s.mu.Lock()
+	s.onSubscribeError = h
+	s.mu.Unlock()
+}
+
+// OnUnsubscribe registers h to be called when a channel is unsubscribed from.
+func (s *Subscriber) OnUnsubscribe(h UnsubscribeHandler) {
+	s.mu.Lock()
+	s.onUnsubscribe = h
+	s.mu.Unlock()
+}
+
+// OnRefresh registers h to be called to mint a new connection token before
+// the current one expires.
+func (s *Subscriber) OnRefresh(h RefreshHandler) {
+	s.mu.Lock()
+	s.onRefresh = h
+	s.mu.Unlock()
+}
+
+// OnPrivateSub registers h to be called to sign subscriptions to private
+// ($-prefixed) channels.
+func (s *Subscriber) OnPrivateSub(h PrivateSubHandler) {
+	s.mu.Lock()
+	s.onPrivateSub = h
+	s.mu.Unlock()
+}
+
+// Connect dials Centrifugo, authenticates and starts the read/write pumps
+// and ping keepalive. If the connection later drops, Subscriber reconnects
+// automatically with exponential backoff. Connect itself makes a single
+// attempt (ctx bounds the dial) and returns its error if that attempt fails,
+// in which case no reconnecting is started.
+func (s *Subscriber) Connect(ctx context.Context) error {
+	if err := s.dialAndAuth(ctx); err != nil {
+		return err
+	}
+	go s.reconnectLoop()
+	return nil
+}
+
+// Close terminates the connection and stops any further reconnect attempts.
+func (s *Subscriber) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	if s.cs != nil {
+		s.cs.stop()
+		return s.cs.conn.Close()
+	}
+	return nil
+}
+
+func (s *Subscriber) dialAndAuth(ctx context.Context) error {
+	dialer := websocket.Dialer{}
+	conn, _, err := dialer.DialContext(ctx, s.config.WSURL, nil)
+	if err != nil {
+		return err
+	}
+
+	cs := newConnState(conn)
+
+	s.mu.Lock()
+	if s.closed {
+		// Close() ran while we were dialing (e.g. during reconnectLoop's
+		// backoff): don't install a connection nothing will ever close.
+		s.mu.Unlock()
+		conn.Close()
+		return ErrSubscriberClosed
+	}
+	if s.cs != nil {
+		// Stop the previous connection's pumps before swapping it out, so
+		// they don't leak (writePump) or keep acting on a dead connection
+		// (pingPump) once this one takes over.
+		s.cs.stop()
+	}
+	s.cs = cs
+	s.pendingReply = make(map[string]chan rawReply)
+	s.mu.Unlock()
+
+	go s.writePump(cs)
+	go s.readPump(cs)
+	go s.pingPump(cs)
+
+	if _, err := s.request("connect", map[string]interface{}{
+		"user":      s.config.User,
+		"timestamp": s.config.Timestamp,
+		"info":      string(s.config.Info),
+		"token":     s.config.Token,
+	}); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	onConnect := s.onConnect
+	s.mu.Unlock()
+	if onConnect != nil {
+		onConnect(s)
+	}
+
+	s.mu.Lock()
+	channels := make([]*subscription, 0, len(s.channels))
+	for _, sub := range s.channels {
+		channels = append(channels, sub)
+	}
+	s.mu.Unlock()
+	for _, sub := range channels {
+		s.resubscribe(sub)
+	}
+
+	return nil
+}
+
+// reconnectLoop watches the current connection for as long as Subscriber is
+// open, redialing with exponential backoff every time it drops, and keeps
+// doing so for every connection it establishes in turn.
+func (s *Subscriber) reconnectLoop() {
+	for {
+		s.mu.Lock()
+		cs := s.cs
+		s.mu.Unlock()
+
+		<-cs.done // signalled by readPump (or Close) when the connection dies
+
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if closed {
+			return
+		}
+
+		s.mu.Lock()
+		onDisconnect := s.onDisconnect
+		s.mu.Unlock()
+		if onDisconnect != nil {
+			onDisconnect(s, errors.New("connection closed"))
+		}
+		_ = cs.conn.Close()
+
+		delay := s.config.ReconnectMinDelay
+		for {
+			time.Sleep(delay + time.Duration(rand.Int63n(int64(delay)/2+1)))
+
+			s.mu.Lock()
+			closed = s.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+
+			if err := s.dialAndAuth(context.Background()); err == nil {
+				break
+			}
+
+			delay *= 2
+			if delay > s.config.ReconnectMaxDelay {
+				delay = s.config.ReconnectMaxDelay
+			}
+		}
+	}
+}
+
+// writePump serves cs only: once cs.stop is called (by dialAndAuth starting
+// a new connection, or by Close) it exits instead of leaking on cs.writeCh,
+// which nothing else drains once cs is no longer the current connection.
+func (s *Subscriber) writePump(cs *connState) {
+	for {
+		select {
+		case data := <-cs.writeCh:
+			if err := cs.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				cs.stop()
+				return
+			}
+		case <-cs.done:
+			return
+		}
+	}
+}
+
+// pingPump serves cs only, so that once a reconnect replaces cs with a new
+// connState this pump stops instead of continuing to ping a connection that
+// is no longer current.
+func (s *Subscriber) pingPump(cs *connState) {
+	ticker := time.NewTicker(s.config.PingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := cs.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				cs.stop()
+				return
+			}
+		case <-cs.done:
+			return
+		}
+	}
+}
+
+func (s *Subscriber) readPump(cs *connState) {
+	defer cs.stop()
+
+	for {
+		_, data, err := cs.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.handleFrame(data)
+	}
+}
+
+func (s *Subscriber) handleFrame(data []byte) {
+	var reply rawReply
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return
+	}
+
+	if reply.UID != "" {
+		s.mu.Lock()
+		ch, ok := s.pendingReply[reply.UID]
+		if ok {
+			delete(s.pendingReply, reply.UID)
+		}
+		s.mu.Unlock()
+		if ok {
+			ch <- reply
+			return
+		}
+	}
+
+	switch reply.Method {
+	case "message":
+		var body struct {
+			Channel string  `json:"channel"`
+			Data    Message `json:"data"`
+		}
+		if json.Unmarshal(reply.Body, &body) == nil {
+			s.mu.Lock()
+			if sub, ok := s.channels[body.Channel]; ok {
+				sub.lastUID = body.Data.UID
+			}
+			onMessage := s.onMessage
+			s.mu.Unlock()
+			if onMessage != nil {
+				onMessage(body.Channel, body.Data)
+			}
+		}
+	case "join":
+		var body struct {
+			Channel string     `json:"channel"`
+			Data    ClientInfo `json:"data"`
+		}
+		s.mu.Lock()
+		onJoin := s.onJoin
+		s.mu.Unlock()
+		if json.Unmarshal(reply.Body, &body) == nil && onJoin != nil {
+			onJoin(body.Channel, body.Data)
+		}
+	case "leave":
+		var body struct {
+			Channel string     `json:"channel"`
+			Data    ClientInfo `json:"data"`
+		}
+		s.mu.Lock()
+		onLeave := s.onLeave
+		s.mu.Unlock()
+		if json.Unmarshal(reply.Body, &body) == nil && onLeave != nil {
+			onLeave(body.Channel, body.Data)
+		}
+	case "unsubscribe":
+		var body struct {
+			Channel string `json:"channel"`
+		}
+		if json.Unmarshal(reply.Body, &body) == nil {
+			s.mu.Lock()
+			delete(s.channels, body.Channel)
+			onUnsubscribe := s.onUnsubscribe
+			s.mu.Unlock()
+			if onUnsubscribe != nil {
+				onUnsubscribe(body.Channel)
+			}
+		}
+	case "refresh":
+		s.mu.Lock()
+		onRefresh := s.onRefresh
+		s.mu.Unlock()
+		if onRefresh != nil {
+			token, err := onRefresh()
+			if err == nil {
+				_, _ = s.request("refresh", map[string]interface{}{"token": token})
+			}
+		}
+	}
+}
+
+// request sends a command and blocks until the correlated reply arrives or
+// ReadTimeout elapses.
+func (s *Subscriber) request(method string, params interface{}) (rawReply, error) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return rawReply{}, ErrSubscriberClosed
+	}
+	cs := s.cs
+	if cs == nil {
+		s.mu.Unlock()
+		return rawReply{}, ErrNotConnected
+	}
+	uid := uuid.NewV4().String()
+	replyCh := make(chan rawReply, 1)
+	s.pendingReply[uid] = replyCh
+	s.mu.Unlock()
+
+	data, err := json.Marshal(clientCommand{UID: uid, Method: method, Params: params})
+	if err != nil {
+		return rawReply{}, err
+	}
+
+	select {
+	case cs.writeCh <- data:
+	case <-time.After(s.config.ReadTimeout):
+		return rawReply{}, ErrTimeout
+	}
+
+	select {
+	case reply := <-replyCh:
+		if reply.Error != "" {
+			return reply, errors.New(reply.Error)
+		}
+		return reply, nil
+	case <-time.After(s.config.ReadTimeout):
+		return rawReply{}, ErrTimeout
+	}
+}
+
+// Subscribe subscribes to a public channel.
+func (s *Subscriber) Subscribe(channel string) error {
+	return s.subscribe(channel, false)
+}
+
+// SubscribePrivate subscribes to a private ($-prefixed) channel, using
+// OnPrivateSub to obtain the channel sign.
+func (s *Subscriber) SubscribePrivate(channel string) error {
+	return s.subscribe(channel, true)
+}
+
+func (s *Subscriber) subscribe(channel string, private bool) error {
+	sub := &subscription{channel: channel, private: private}
+
+	s.mu.Lock()
+	s.channels[channel] = sub
+	s.mu.Unlock()
+
+	err := s.resubscribe(sub)
+	if err != nil {
+		s.mu.Lock()
+		onSubscribeError := s.onSubscribeError
+		s.mu.Unlock()
+		if onSubscribeError != nil {
+			onSubscribeError(channel, err)
+		}
+	}
+	return err
+}
+
+func (s *Subscriber) resubscribe(sub *subscription) error {
+	params := map[string]interface{}{
+		"channel": sub.channel,
+	}
+	if sub.lastUID != "" {
+		params["recover"] = true
+		params["last"] = sub.lastUID
+	}
+	if sub.private {
+		s.mu.Lock()
+		onPrivateSub := s.onPrivateSub
+		s.mu.Unlock()
+		if onPrivateSub == nil {
+			return errors.New("gocent: OnPrivateSub handler required for private channel " + sub.channel)
+		}
+		sign, err := onPrivateSub(sub.channel)
+		if err != nil {
+			return err
+		}
+		params["sign"] = sign
+	}
+
+	_, err := s.request("subscribe", params)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	sub.subscribed = true
+	onSubscribeSuccess := s.onSubscribeSuccess
+	s.mu.Unlock()
+
+	if onSubscribeSuccess != nil {
+		onSubscribeSuccess(sub.channel)
+	}
+	return nil
+}
+
+// Unsubscribe unsubscribes from channel.
+func (s *Subscriber) Unsubscribe(channel string) error {
+	_, err := s.request("unsubscribe", map[string]interface{}{"channel": channel})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.channels, channel)
+	onUnsubscribe := s.onUnsubscribe
+	s.mu.Unlock()
+	if onUnsubscribe != nil {
+		onUnsubscribe(channel)
+	}
+	return nil
+}
+
+// Publish publishes data to channel over the live connection rather than the
+// server API; it is useful for client-originated events in setups where
+// Centrifugo allows client-side publish.
+func (s *Subscriber) Publish(channel string, data []byte) error {
+	var raw json.RawMessage = data
+	_, err := s.request("publish", map[string]interface{}{
+		"channel": channel,
+		"data":    &raw,
+	})
+	return err
+}
@@ -0,0 +1,304 @@
+package gocent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testServer is a minimal Centrifugo-protocol-speaking WebSocket server used
+// to exercise Subscriber end to end without a real Centrifugo instance.
+// onCommand is invoked for every decoded clientCommand, on the goroutine
+// reading that connection, so it can write replies back on the same conn.
+type testServer struct {
+	*httptest.Server
+	upgrader  websocket.Upgrader
+	onCommand func(conn *websocket.Conn, cmd clientCommand)
+
+	mu       sync.Mutex
+	conns    []*websocket.Conn
+	commands []clientCommand
+}
+
+func newTestServer(t *testing.T, onCommand func(conn *websocket.Conn, cmd clientCommand)) *testServer {
+	ts := &testServer{
+		upgrader:  websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }},
+		onCommand: onCommand,
+	}
+	ts.Server = httptest.NewServer(http.HandlerFunc(ts.handle))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func (ts *testServer) handle(w http.ResponseWriter, r *http.Request) {
+	conn, err := ts.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	ts.mu.Lock()
+	ts.conns = append(ts.conns, conn)
+	ts.mu.Unlock()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var cmd clientCommand
+		if json.Unmarshal(data, &cmd) != nil {
+			continue
+		}
+		ts.mu.Lock()
+		ts.commands = append(ts.commands, cmd)
+		ts.mu.Unlock()
+		ts.onCommand(conn, cmd)
+	}
+}
+
+func (ts *testServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+// connCount returns how many client connections have been accepted so far.
+func (ts *testServer) connCount() int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return len(ts.conns)
+}
+
+// conn returns the i-th accepted connection (0-indexed), waiting for it to
+// show up if necessary.
+func (ts *testServer) conn(t *testing.T, i int) *websocket.Conn {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		ts.mu.Lock()
+		if i < len(ts.conns) {
+			c := ts.conns[i]
+			ts.mu.Unlock()
+			return c
+		}
+		ts.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("connection %d never arrived", i)
+	return nil
+}
+
+func (ts *testServer) commandCount(method string) int {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	n := 0
+	for _, c := range ts.commands {
+		if c.Method == method {
+			n++
+		}
+	}
+	return n
+}
+
+func writeReply(t *testing.T, conn *websocket.Conn, reply rawReply) {
+	t.Helper()
+	data, err := json.Marshal(reply)
+	if err != nil {
+		t.Fatalf("marshaling reply: %v", err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		t.Fatalf("writing reply: %v", err)
+	}
+}
+
+// acceptAndSubscribe replies success to every connect/subscribe command,
+// mirroring the minimum a Centrifugo server must do for Subscriber.Connect
+// and Subscriber.Subscribe to succeed.
+func acceptAndSubscribe(t *testing.T) (*testServer, func(method string) int) {
+	ts := newTestServer(t, func(conn *websocket.Conn, cmd clientCommand) {
+		switch cmd.Method {
+		case "connect", "subscribe", "unsubscribe", "publish":
+			writeReply(t, conn, rawReply{UID: cmd.UID})
+		}
+	})
+	return ts, ts.commandCount
+}
+
+func waitFor(t *testing.T, what string, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if fn() {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestSubscriberConnectAuthenticates(t *testing.T) {
+	ts, _ := acceptAndSubscribe(t)
+
+	var connected int32
+	sub := NewSubscriber(SubscriberConfig{
+		WSURL:       ts.wsURL(),
+		User:        "user-1",
+		Token:       "tok",
+		ReadTimeout: time.Second,
+	})
+	sub.OnConnect(func(*Subscriber) { atomic.AddInt32(&connected, 1) })
+
+	if err := sub.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer sub.Close()
+
+	if atomic.LoadInt32(&connected) != 1 {
+		t.Errorf("OnConnect called %d times, want 1", connected)
+	}
+
+	ts.conn(t, 0)
+	if ts.commandCount("connect") != 1 {
+		t.Errorf("server saw %d connect commands, want 1", ts.commandCount("connect"))
+	}
+}
+
+func TestSubscriberSubscribeAndDispatchMessage(t *testing.T) {
+	gotMessage := make(chan Message, 1)
+	gotChannel := make(chan string, 1)
+
+	ts := newTestServer(t, func(conn *websocket.Conn, cmd clientCommand) {
+		switch cmd.Method {
+		case "connect":
+			writeReply(t, conn, rawReply{UID: cmd.UID})
+		case "subscribe":
+			writeReply(t, conn, rawReply{UID: cmd.UID})
+
+			body, err := json.Marshal(struct {
+				Channel string  `json:"channel"`
+				Data    Message `json:"data"`
+			}{Channel: "news", Data: Message{UID: "msg-1"}})
+			if err != nil {
+				t.Fatalf("marshaling message push: %v", err)
+			}
+			// Built with json.RawMessage so the body goes out as a JSON
+			// object, the way handleFrame decodes it.
+			frame, err := json.Marshal(struct {
+				Method string          `json:"method"`
+				Body   json.RawMessage `json:"body"`
+			}{Method: "message", Body: body})
+			if err != nil {
+				t.Fatalf("marshaling message frame: %v", err)
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, frame); err != nil {
+				t.Fatalf("writing message frame: %v", err)
+			}
+		}
+	})
+
+	sub := NewSubscriber(SubscriberConfig{WSURL: ts.wsURL(), ReadTimeout: time.Second})
+	sub.OnMessage(func(channel string, msg Message) {
+		gotChannel <- channel
+		gotMessage <- msg
+	})
+
+	if err := sub.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer sub.Close()
+
+	if err := sub.Subscribe("news"); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	select {
+	case channel := <-gotChannel:
+		if channel != "news" {
+			t.Errorf("dispatched channel = %q, want news", channel)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for dispatched message")
+	}
+	select {
+	case msg := <-gotMessage:
+		if msg.UID != "msg-1" {
+			t.Errorf("dispatched message UID = %q, want msg-1", msg.UID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for dispatched message")
+	}
+}
+
+func TestSubscriberReconnectsAndResubscribes(t *testing.T) {
+	ts, commandCount := acceptAndSubscribe(t)
+
+	var connects int32
+	sub := NewSubscriber(SubscriberConfig{
+		WSURL:             ts.wsURL(),
+		ReadTimeout:       time.Second,
+		ReconnectMinDelay: time.Millisecond,
+		ReconnectMaxDelay: 10 * time.Millisecond,
+	})
+	sub.OnConnect(func(*Subscriber) { atomic.AddInt32(&connects, 1) })
+
+	if err := sub.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer sub.Close()
+
+	if err := sub.Subscribe("news"); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	waitFor(t, "first connect", func() bool { return atomic.LoadInt32(&connects) == 1 })
+
+	// Sever the first connection from the server side; Subscriber's
+	// readPump should notice and reconnectLoop should redial.
+	ts.conn(t, 0).Close()
+
+	waitFor(t, "reconnect", func() bool { return atomic.LoadInt32(&connects) == 2 })
+	waitFor(t, "resubscribe after reconnect", func() bool { return commandCount("subscribe") == 2 })
+}
+
+func TestSubscriberCloseStopsReconnect(t *testing.T) {
+	ts, _ := acceptAndSubscribe(t)
+
+	sub := NewSubscriber(SubscriberConfig{
+		WSURL:             ts.wsURL(),
+		ReadTimeout:       time.Second,
+		ReconnectMinDelay: time.Millisecond,
+		ReconnectMaxDelay: 10 * time.Millisecond,
+	})
+	if err := sub.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	if err := sub.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	connsAfterClose := ts.connCount()
+	time.Sleep(20 * time.Millisecond)
+	if ts.connCount() != connsAfterClose {
+		t.Errorf("connection count grew from %d to %d after Close, reconnect loop should have stopped", connsAfterClose, ts.connCount())
+	}
+
+	if _, err := sub.request("subscribe", nil); err != ErrSubscriberClosed {
+		t.Errorf("request() after Close error = %v, want ErrSubscriberClosed", err)
+	}
+}
+
+func TestSubscriberRequestBeforeConnectReturnsNotConnected(t *testing.T) {
+	sub := NewSubscriber(SubscriberConfig{WSURL: "ws://unused.invalid", ReadTimeout: time.Second})
+
+	if err := sub.Subscribe("news"); err != ErrNotConnected {
+		t.Errorf("Subscribe() before Connect error = %v, want ErrNotConnected", err)
+	}
+	if err := sub.Publish("news", []byte("{}")); err != ErrNotConnected {
+		t.Errorf("Publish() before Connect error = %v, want ErrNotConnected", err)
+	}
+}
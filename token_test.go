@@ -0,0 +1,142 @@
+package gocent
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func splitJWT(t *testing.T, token string) (header, payload, sig string) {
+	t.Helper()
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token has %d parts, want 3: %q", len(parts), token)
+	}
+	return parts[0], parts[1], parts[2]
+}
+
+func TestGenerateConnectionTokenHS256(t *testing.T) {
+	claims := ConnectionClaims{Sub: "42", Exp: 1000}
+
+	token, err := GenerateConnectionTokenHS256("secret", claims)
+	if err != nil {
+		t.Fatalf("GenerateConnectionTokenHS256() error = %v", err)
+	}
+
+	header, payload, _ := splitJWT(t, token)
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		t.Fatalf("decoding header: %v", err)
+	}
+	if !strings.Contains(string(headerJSON), `"alg":"HS256"`) {
+		t.Errorf("header = %s, want alg HS256", headerJSON)
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	var got ConnectionClaims
+	if err := json.Unmarshal(payloadJSON, &got); err != nil {
+		t.Fatalf("unmarshaling payload: %v", err)
+	}
+	if !reflect.DeepEqual(got, claims) {
+		t.Errorf("payload claims = %+v, want %+v", got, claims)
+	}
+}
+
+func TestGenerateConnectionTokenHS256Deterministic(t *testing.T) {
+	claims := ConnectionClaims{Sub: "42"}
+
+	t1, err := GenerateConnectionTokenHS256("secret", claims)
+	if err != nil {
+		t.Fatalf("GenerateConnectionTokenHS256() error = %v", err)
+	}
+	t2, err := GenerateConnectionTokenHS256("secret", claims)
+	if err != nil {
+		t.Fatalf("GenerateConnectionTokenHS256() error = %v", err)
+	}
+	if t1 != t2 {
+		t.Errorf("HS256 token not deterministic: %q != %q", t1, t2)
+	}
+
+	t3, err := GenerateConnectionTokenHS256("other-secret", claims)
+	if err != nil {
+		t.Fatalf("GenerateConnectionTokenHS256() error = %v", err)
+	}
+	if t1 == t3 {
+		t.Error("tokens signed with different secrets should differ")
+	}
+}
+
+func TestGenerateSubscribeTokenHS256(t *testing.T) {
+	claims := SubscribeClaims{Client: "c1", Channel: "$news"}
+
+	token, err := GenerateSubscribeTokenHS256("secret", claims)
+	if err != nil {
+		t.Fatalf("GenerateSubscribeTokenHS256() error = %v", err)
+	}
+
+	_, payload, _ := splitJWT(t, token)
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatalf("decoding payload: %v", err)
+	}
+	var got SubscribeClaims
+	if err := json.Unmarshal(payloadJSON, &got); err != nil {
+		t.Fatalf("unmarshaling payload: %v", err)
+	}
+	if !reflect.DeepEqual(got, claims) {
+		t.Errorf("payload claims = %+v, want %+v", got, claims)
+	}
+}
+
+func TestGenerateConnectionTokenRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	claims := ConnectionClaims{Sub: "42"}
+
+	token, err := GenerateConnectionTokenRS256(key, claims)
+	if err != nil {
+		t.Fatalf("GenerateConnectionTokenRS256() error = %v", err)
+	}
+
+	header, payload, sig := splitJWT(t, token)
+	if !strings.Contains(mustDecode(t, header), `"alg":"RS256"`) {
+		t.Errorf("header = %s, want alg RS256", mustDecode(t, header))
+	}
+
+	sigBytes, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		t.Fatalf("decoding signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(header + "." + payload))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], sigBytes); err != nil {
+		t.Errorf("signature does not verify against the signer's public key: %v", err)
+	}
+}
+
+func TestGenerateConnectionTokenRS256NoSigner(t *testing.T) {
+	_, err := GenerateConnectionTokenRS256(nil, ConnectionClaims{})
+	if err != ErrNoSigner {
+		t.Errorf("error = %v, want ErrNoSigner", err)
+	}
+}
+
+func mustDecode(t *testing.T, s string) string {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decoding %q: %v", s, err)
+	}
+	return string(b)
+}
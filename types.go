@@ -0,0 +1,81 @@
+package gocent
+
+import (
+	json "github.com/json-iterator/go"
+)
+
+// Command is a single API command, either sent on its own via one of
+// Client's one-shot helpers (Publish, Presence, ...) or buffered with
+// Add* and flushed together by Send. UID is assigned by Client.add and is
+// how SendWithUIDs correlates a Result entry back to the command that
+// produced it.
+type Command struct {
+	UID    string      `json:"uid"`
+	Method string      `json:"method"`
+	Params interface{} `json:"params"`
+}
+
+// Response is the server's reply to a single Command. Body carries the
+// command-specific payload (see the Decode* helpers) and is only populated
+// for commands that return data (Presence, History, Channels, Stats).
+type Response struct {
+	UID   string          `json:"uid,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Body  json.RawMessage `json:"body,omitempty"`
+}
+
+// Result is the server's reply to a batch of Commands, in the same order
+// Client.Send sent them.
+type Result []Response
+
+// ClientInfo describes a single client connected to a channel, as returned
+// by Presence and delivered with join/leave events.
+type ClientInfo struct {
+	User     string          `json:"user"`
+	Client   string          `json:"client"`
+	ConnInfo json.RawMessage `json:"conn_info,omitempty"`
+	ChanInfo json.RawMessage `json:"chan_info,omitempty"`
+}
+
+// Message is a single publication delivered to a channel, returned by
+// History and dispatched to Subscriber's MessageHandler.
+type Message struct {
+	UID  string          `json:"uid"`
+	Data json.RawMessage `json:"data"`
+}
+
+// NodeInfo describes a single node in the Centrifugo cluster, as returned by
+// Stats.
+type NodeInfo struct {
+	UID         string `json:"uid"`
+	NumClients  uint32 `json:"num_clients"`
+	NumUsers    uint32 `json:"num_users"`
+	NumChannels uint32 `json:"num_channels"`
+	Uptime      int64  `json:"uptime"`
+}
+
+// Stats is the response to a stats command: one NodeInfo per node in the
+// cluster.
+type Stats struct {
+	Nodes []NodeInfo `json:"nodes"`
+}
+
+// historyBody is the decoded shape of a history Response.Body.
+type historyBody struct {
+	Data []Message `json:"data"`
+}
+
+// channelsBody is the decoded shape of a channels Response.Body.
+type channelsBody struct {
+	Data []string `json:"data"`
+}
+
+// statsBody is the decoded shape of a stats Response.Body.
+type statsBody struct {
+	Data Stats `json:"data"`
+}
+
+// presenceBody is the decoded shape of a presence Response.Body.
+type presenceBody struct {
+	Data map[string]ClientInfo `json:"data"`
+}